@@ -0,0 +1,230 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type CompressionOptions struct {
+	// the encoders in the order the server prefers them, default is gzip then deflate,
+	// other codings like brotli can be added by implementing the Encoder interface
+	Encoders []Encoder
+	// the minimum size of the body in bytes to compress, default is 1024
+	MinSize int
+	// the content types to compress, a type ending with /* matches all its subtypes,
+	// default is text/*, json, javascript, xml and svg
+	ContentTypes []string
+}
+
+// Encoder compresses the bodies of the responses with a content coding
+type Encoder interface {
+	// Encoding returns the name of the content coding, e.g. gzip
+	Encoding() string
+	Encode(w io.Writer, body []byte) error
+}
+
+var defaultCompressibleTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/problem+json",
+	"image/svg+xml",
+}
+
+type compression struct {
+	opts  CompressionOptions
+	types map[string]bool
+	// the content types ending with /* without the *
+	typePrefixes []string
+}
+
+// Compress returns a middleware that compresses the responses with the best encoding the client accepts
+// in its Accept-Encoding header, the streamed responses, the responses that already have a Content-Encoding
+// and the responses smaller than the minimum size or with other content types are sent as they are
+func Compress(opts ...CompressionOptions) Middleware {
+	var opt CompressionOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if len(opt.Encoders) == 0 {
+		opt.Encoders = []Encoder{GzipEncoder(gzip.DefaultCompression), DeflateEncoder(zlib.DefaultCompression)}
+	}
+	if opt.MinSize == 0 {
+		opt.MinSize = 1024
+	}
+	if len(opt.ContentTypes) == 0 {
+		opt.ContentTypes = defaultCompressibleTypes
+	}
+	cp := &compression{
+		opts:  opt,
+		types: map[string]bool{},
+	}
+	for _, t := range opt.ContentTypes {
+		t = strings.ToLower(t)
+		if strings.HasSuffix(t, "/*") {
+			cp.typePrefixes = append(cp.typePrefixes, strings.TrimSuffix(t, "*"))
+			continue
+		}
+		cp.types[t] = true
+	}
+	return Around(func(c *Context, next func() *Response) *Response {
+		res := next()
+		cp.compress(c, res)
+		return res
+	})
+}
+
+func (cp *compression) compress(c *Context, rs *Response) {
+	if rs.IsStreamed() || rs.redirectTo != "" || rs.GetHeader("Content-Encoding") != "" {
+		return
+	}
+	code := rs.GetStatusCode()
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		return
+	}
+	if !cp.compressible(rs.GetContentType()) {
+		return
+	}
+	rs.forceHeader("Vary", "Accept-Encoding")
+	if len(rs.body) < cp.opts.MinSize || strings.Contains(rs.GetHeader("Cache-Control"), "no-transform") {
+		return
+	}
+	enc := negotiateEncoding(c.Request.httpRequest.Header.Get("Accept-Encoding"), cp.opts.Encoders)
+	if enc == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, rs.body); err != nil {
+		c.requestLogger().Error(fmt.Sprintf("error compressing the response with %v: %v", enc.Encoding(), err))
+		return
+	}
+	if buf.Len() >= len(rs.body) {
+		return
+	}
+	rs.body = buf.Bytes()
+	rs.delHeader("Content-Length")
+	rs.forceHeader("Content-Encoding", enc.Encoding())
+	// the compressed body is not identical to the original one byte for byte, so a strong
+	// etag is weakened, it still matches the If-None-Match of the original etag
+	if etag := rs.GetHeader("ETag"); strings.HasPrefix(etag, "\"") {
+		rs.delHeader("ETag")
+		rs.forceHeader("ETag", "W/"+etag)
+	}
+}
+
+func (cp *compression) compressible(contentType string) bool {
+	mt := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if cp.types[mt] {
+		return true
+	}
+	for _, p := range cp.typePrefixes {
+		if strings.HasPrefix(mt, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding returns the encoder with the highest quality in the Accept-Encoding header,
+// the encoders that have the same quality are chosen in the server's order of preference
+func negotiateEncoding(acceptEncoding string, encoders []Encoder) Encoder {
+	if acceptEncoding == "" {
+		return nil
+	}
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		qualities[name] = q
+	}
+	var best Encoder
+	bestQ := 0.0
+	for _, enc := range encoders {
+		q, ok := qualities[enc.Encoding()]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best = enc
+			bestQ = q
+		}
+	}
+	return best
+}
+
+// GzipEncoder returns an encoder that compresses with gzip at the given level, e.g. gzip.BestSpeed
+func GzipEncoder(level int) Encoder {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(fmt.Sprintf("invalid gzip compression level %v", level))
+	}
+	return &pooledEncoder{
+		encoding: "gzip",
+		pool: sync.Pool{New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+	}
+}
+
+// DeflateEncoder returns an encoder that compresses with deflate at the given level, e.g. zlib.BestSpeed,
+// the body is wrapped in the zlib format as the deflate content coding requires
+func DeflateEncoder(level int) Encoder {
+	if _, err := zlib.NewWriterLevel(io.Discard, level); err != nil {
+		panic(fmt.Sprintf("invalid deflate compression level %v", level))
+	}
+	return &pooledEncoder{
+		encoding: "deflate",
+		pool: sync.Pool{New: func() interface{} {
+			w, _ := zlib.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+	}
+}
+
+type resettableWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// pooledEncoder reuses the writers, they allocate large buffers
+type pooledEncoder struct {
+	encoding string
+	pool     sync.Pool
+}
+
+func (e *pooledEncoder) Encoding() string {
+	return e.encoding
+}
+
+func (e *pooledEncoder) Encode(w io.Writer, body []byte) error {
+	zw := e.pool.Get().(resettableWriter)
+	defer e.pool.Put(zw)
+	zw.Reset(w)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	encoders := []Encoder{GzipEncoder(gzip.DefaultCompression), DeflateEncoder(zlib.DefaultCompression)}
+	cases := map[string]string{
+		"":                       "",
+		"gzip, deflate, br":      "gzip",
+		"deflate":                "deflate",
+		"gzip;q=0.5, deflate":    "deflate",
+		"gzip;q=0, deflate;q=0":  "",
+		"*":                      "gzip",
+		"*;q=0.1, gzip;q=0":      "deflate",
+		"br, identity":           "",
+		"GZIP;q=1.0":             "gzip",
+		"gzip;q=invalid, defl=1": "",
+	}
+	for header, expected := range cases {
+		enc := negotiateEncoding(header, encoders)
+		found := ""
+		if enc != nil {
+			found = enc.Encoding()
+		}
+		if found != expected {
+			t.Errorf("failed negotiating the encoding of %q, expected %q found %q", header, expected, found)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	large := "[" + strings.Repeat("{\"name\": \"jack\", \"role\": \"admin\"},", 100) + "{}]"
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response { return c.Response.Json(large) }))
+	r.Get("/small", Handler(func(c *Context) *Response { return c.Response.Json("[]") }))
+	r.Get("/image", Handler(func(c *Context) *Response {
+		return c.Response.SetContentType("image/png").Any(large)
+	}))
+	r.Get("/encoded", Handler(func(c *Context) *Response {
+		return c.Response.SetHeader("Content-Encoding", "gzip").Text(large)
+	}))
+	r.Get("/stream", Handler(func(c *Context) *Response {
+		c.Response.stream = func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(large)) }
+		return c.Response.SetContentType(CONTENT_TYPE_TEXT)
+	}))
+	r.Get("/panic", Handler(func(c *Context) *Response {
+		panic(NewHTTPError(http.StatusBadRequest, strings.Repeat("bad request ", 200)))
+	}))
+	UseMiddleware(Compress())
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	serve := func(path string, acceptEncoding string) *http.Response {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	rsp := serve("/users", "gzip, deflate")
+	if rsp.Header.Get("Content-Encoding") != "gzip" || rsp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("failed compressing the response with gzip, found %v", rsp.Header)
+	}
+	zr, err := gzip.NewReader(rsp.Body)
+	if err != nil {
+		t.Fatalf("failed reading the gzip body: %v", err)
+	}
+	b, _ := io.ReadAll(zr)
+	if string(b) != large {
+		t.Errorf("failed decompressing the gzip body")
+	}
+
+	rsp = serve("/users", "gzip;q=0.2, deflate")
+	if rsp.Header.Get("Content-Encoding") != "deflate" {
+		t.Fatalf("failed compressing the response with deflate, found %v", rsp.Header)
+	}
+	zlr, err := zlib.NewReader(rsp.Body)
+	if err != nil {
+		t.Fatalf("failed reading the deflate body: %v", err)
+	}
+	b, _ = io.ReadAll(zlr)
+	if string(b) != large {
+		t.Errorf("failed decompressing the deflate body")
+	}
+
+	rsp = serve("/users", "")
+	b, _ = io.ReadAll(rsp.Body)
+	if rsp.Header.Get("Content-Encoding") != "" || rsp.Header.Get("Vary") != "Accept-Encoding" || string(b) != large {
+		t.Errorf("expected the response of a client without Accept-Encoding to be sent as it is, found %v", rsp.Header)
+	}
+
+	rsp = serve("/small", "gzip")
+	if rsp.Header.Get("Content-Encoding") != "" || rsp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Errorf("expected the response under the minimum size to be sent as it is, found %v", rsp.Header)
+	}
+
+	for _, path := range []string{"/image", "/encoded", "/stream"} {
+		rsp = serve(path, "gzip")
+		b, _ = io.ReadAll(rsp.Body)
+		if string(b) != large || rsp.Header.Get("Vary") != "" {
+			t.Errorf("expected the response of %v to be sent as it is, found %v", path, rsp.Header)
+		}
+	}
+
+	rsp = serve("/panic", "gzip")
+	if rsp.StatusCode != http.StatusBadRequest || rsp.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("failed compressing the error response, found %v %v", rsp.StatusCode, rsp.Header)
+	}
+}
@@ -15,3 +15,13 @@ const CONTENT_TYPE_MULTIPART_FORM_DATA string = "multipart/form-data;"
 const LOCALHOST string = "http://localhost"
 const TEST_STR string = "Testing!"
 const PRODUCTION string = "production"
+const RESOURCE_INDEX string = "index"
+const RESOURCE_CREATE string = "create"
+const RESOURCE_STORE string = "store"
+const RESOURCE_SHOW string = "show"
+const RESOURCE_EDIT string = "edit"
+const RESOURCE_UPDATE string = "update"
+const RESOURCE_DESTROY string = "destroy"
+const RATE_LIMIT_SLIDING_WINDOW string = "sliding-window"
+const RATE_LIMIT_TOKEN_BUCKET string = "token-bucket"
+const CSP_NONCE string = "{nonce}"
@@ -0,0 +1,95 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// the resolvers are shared by the contexts of all requests
+var (
+	validatorResolver = getValidator()
+	jwtResolver       = getJWT()
+	gormResolver      = getGormFunc()
+	cacheResolver     = resolveCache()
+	hashingResolver   = resloveHashing()
+	mailerResolver    = resolveMailer()
+)
+
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		return newContext()
+	},
+}
+
+func newContext() *Context {
+	c := &Context{
+		Request: &Request{},
+		Response: &Response{
+			headers: []header{},
+		},
+		GetValidator: validatorResolver,
+		GetJWT:       jwtResolver,
+		GetGorm:      gormResolver,
+		GetCache:     cacheResolver,
+		GetHashing:   hashingResolver,
+		GetMailer:    mailerResolver,
+	}
+	c.GetEventsManager = resolveEventsManager(c)
+	c.GetLogger = resolveLogger(c)
+	return c
+}
+
+// acquireContext returns a context from the pool prepared for the given request
+func acquireContext(app *App, w http.ResponseWriter, r *http.Request, ps httprouter.Params) *Context {
+	c := contextPool.Get().(*Context)
+	c.released = false
+	c.Request.httpRequest = r
+	c.Request.httpPathParams = ps
+	c.Response.released = false
+	c.Response.HttpResponseWriter = w
+	c.app = app
+	if e := ResolveEventsManager(); e != nil {
+		c.events = e.forRequest(c)
+	}
+	return c
+}
+
+// releaseContext resets the context and returns it to the pool, the context must
+// not be used after it's released, e.g. in goroutines started by the handler
+func releaseContext(c *Context) {
+	c.Request.httpRequest = nil
+	c.Request.httpPathParams = nil
+	c.Response.release()
+	for key := range c.values {
+		delete(c.values, key)
+	}
+	c.app = nil
+	c.apiVersion = ""
+	c.chain = nil
+	c.t = 0
+	c.events = nil
+	for i := range c.terminators {
+		c.terminators[i] = nil
+	}
+	c.terminators = c.terminators[:0]
+	c.csrfToken = nil
+	c.csrfField = ""
+	c.requestID = ""
+	c.logger = nil
+	c.cspNonce = ""
+	c.released = true
+	contextPool.Put(c)
+}
+
+// checkReleased panics if the context is used after the request is finished
+func (c *Context) checkReleased() {
+	if c.released {
+		panic("the context is used after the request is finished, copy the values you need before starting goroutines")
+	}
+}
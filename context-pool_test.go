@@ -0,0 +1,68 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestContextReleaseResets(t *testing.T) {
+	app := createNewApp(t)
+	c := acquireContext(app, httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil), httprouter.Params{{Key: "id", Value: "1"}})
+	c.Set("user", "jack")
+	c.apiVersion = "v2"
+	c.Response.SetHeader("X-Test", "yes").SetStatusCode(http.StatusCreated).SetContentType(CONTENT_TYPE_JSON).Text("created")
+	res := c.Response
+	releaseContext(c)
+	if c.Request.httpRequest != nil || c.Request.httpPathParams != nil || len(c.values) != 0 || c.apiVersion != "" || c.app != nil || c.chain != nil {
+		t.Errorf("failed resetting the context on release")
+	}
+	if len(res.headers) != 0 || res.statusCode != 0 || res.body != nil || res.contentType != "" || res.overrideContentType != "" || res.HttpResponseWriter != nil {
+		t.Errorf("failed resetting the response on release")
+	}
+
+	c = acquireContext(app, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	defer releaseContext(c)
+	if c.released || c.Response.released || c.Get("user") != nil || c.Response.HttpResponseWriter == nil {
+		t.Errorf("failed acquiring a clean context")
+	}
+	c.Set("user", "jill")
+	if c.Get("user") != "jill" {
+		t.Errorf("failed storing a value in the context")
+	}
+}
+
+func TestContextUseAfterRelease(t *testing.T) {
+	app := createNewApp(t)
+	c := acquireContext(app, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	releaseContext(c)
+	uses := map[string]func(){
+		"Get":            func() { c.Get("user") },
+		"Set":            func() { c.Set("user", "jack") },
+		"Next":           func() { c.Next() },
+		"GetHeader":      func() { c.GetHeader("Accept") },
+		"GetPathParam":   func() { c.GetPathParam("id") },
+		"PathParamInt":   func() { c.PathParamInt("id") },
+		"PathParamInt64": func() { c.PathParamInt64("id") },
+		"PathParamFloat": func() { c.PathParamFloat("price") },
+		"PathParamUUID":  func() { c.PathParamUUID("id") },
+		"Text":           func() { c.Response.Text("late") },
+		"SetHeader":      func() { c.Response.SetHeader("X-Late", "yes") },
+	}
+	for name, use := range uses {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected %v to panic after the context is released", name)
+				}
+			}()
+			use()
+		}()
+	}
+}
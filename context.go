@@ -13,11 +13,13 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/gocondor/core/logger"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -32,6 +34,29 @@ type Context struct {
 	GetMailer        func() *Mailer
 	GetEventsManager func() *EventsManager
 	GetLogger        func() *logger.Logger
+	app              *App
+	apiVersion       string
+	// the middlewares and handler of the request, and the index of the running one
+	chain *chain
+	t     int
+	// the events manager of the request, it processes the fired events after the response is sent
+	events *EventsManager
+	// the values stored for the request, e.g. by middlewares for the handler
+	values map[string]interface{}
+	// the hooks that run after the response is sent
+	terminators []func(c *Context)
+	// the raw csrf token of the client and the name of its form field, set by the csrf middleware
+	csrfToken []byte
+	csrfField string
+	// the id of the request set by the request id middleware, and the logger that prefixes the lines with it
+	requestID string
+	logger    *logger.Logger
+	// the nonce of the content security policy set by the security headers middleware
+	cspNonce string
+	// the context is returned to the pool after the request is finished
+	released bool
+	// set on the context a middleware is called with to report its name instead of running, see middlewareName
+	describing *string
 }
 
 // TODO enhance
@@ -47,31 +72,135 @@ func (c *Context) DebugAny(variable interface{}) {
 	c.Response.HttpResponseWriter.Write([]byte(formatted))
 }
 
+// Next runs the next middleware or the handler in the request's chain
 func (c *Context) Next() {
-	ResolveApp().Next(c)
+	c.checkReleased()
+	if c.chain == nil {
+		return
+	}
+	c.t = c.t + 1
+	c.chain.run(c.t, c)
 }
 
 func (c *Context) prepare(ctx *Context) {
 	ctx.Request.httpRequest.ParseMultipartForm(int64(app.Config.Request.MaxUploadFileSize))
 }
 
+// Set stores a value for the request, e.g. a middleware can store the authenticated user for the handler
+func (c *Context) Set(key string, val interface{}) {
+	c.checkReleased()
+	if c.values == nil {
+		c.values = map[string]interface{}{}
+	}
+	c.values[key] = val
+}
+
+// Get returns the value stored for the request with the given key, or nil if it's not set
+func (c *Context) Get(key string) interface{} {
+	c.checkReleased()
+	return c.values[key]
+}
+
+// OnTerminate registers a hook that runs in a new goroutine after the response is sent to the
+// client, panics in the hook are recovered and logged, the request's body and response writer
+// must not be used in the hook
+func (c *Context) OnTerminate(f func(c *Context)) {
+	c.checkReleased()
+	c.terminators = append(c.terminators, f)
+}
+
+// terminate runs the hooks registered for the request in the order they were registered
+func (c *Context) terminate() {
+	for _, f := range c.terminators {
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					c.requestLogger().Error(fmt.Sprintf("panic in terminate hook: %v", e))
+					c.requestLogger().Error(string(debug.Stack()))
+				}
+			}()
+			f(c)
+		}()
+	}
+}
+
 func (c *Context) GetPathParam(key string) interface{} {
+	c.checkReleased()
 	return c.Request.httpPathParams.ByName(key)
 }
 
+// PathParamInt returns the path param as an int, or an error if it's not a valid int
+func (c *Context) PathParamInt(key string) (int, error) {
+	c.checkReleased()
+	val := c.Request.httpPathParams.ByName(key)
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("path param %v is not a valid int: %q", key, val)
+	}
+	return i, nil
+}
+
+// PathParamInt64 returns the path param as an int64, or an error if it's not a valid int64
+func (c *Context) PathParamInt64(key string) (int64, error) {
+	c.checkReleased()
+	val := c.Request.httpPathParams.ByName(key)
+	i, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("path param %v is not a valid int64: %q", key, val)
+	}
+	return i, nil
+}
+
+// PathParamFloat returns the path param as a float64, or an error if it's not a valid float
+func (c *Context) PathParamFloat(key string) (float64, error) {
+	c.checkReleased()
+	val := c.Request.httpPathParams.ByName(key)
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("path param %v is not a valid float: %q", key, val)
+	}
+	return f, nil
+}
+
+// PathParamUUID returns the path param as a uuid, or an error if it's not a valid uuid
+func (c *Context) PathParamUUID(key string) (uuid.UUID, error) {
+	c.checkReleased()
+	val := c.Request.httpPathParams.ByName(key)
+	u, err := uuid.Parse(val)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("path param %v is not a valid uuid: %q", key, val)
+	}
+	return u, nil
+}
+
+// GetHostParam returns the value of a placeholder in the host pattern of a domain route
+func (c *Context) GetHostParam(key string) interface{} {
+	c.checkReleased()
+	return hostParamsFromRequest(c.Request.httpRequest)[key]
+}
+
+// URL generates the url of the route with the given name
+func (c *Context) URL(name string, params map[string]interface{}) (string, error) {
+	return ResolveRouter().URL(name, params)
+}
+
 func (c *Context) GetRequestParam(key string) interface{} {
+	c.checkReleased()
 	return c.Request.httpRequest.FormValue(key)
 }
 
 func (c *Context) RequestParamExists(key string) bool {
+	c.checkReleased()
 	return c.Request.httpRequest.Form.Has(key)
 }
 
 func (c *Context) GetHeader(key string) string {
+	c.checkReleased()
 	return c.Request.httpRequest.Header.Get(key)
 }
 
 func (c *Context) GetUploadedFile(name string) *UploadedFileInfo {
+	c.checkReleased()
 	file, fileHeader, err := c.Request.httpRequest.FormFile(name)
 	if err != nil {
 		panic(fmt.Sprintf("error with file,[%v]", err.Error()))
@@ -172,6 +172,41 @@ func TestGetPathParams(t *testing.T) {
 	}
 }
 
+func TestTypedPathParams(t *testing.T) {
+	c := makeCTX(t)
+	c.Request.httpPathParams = httprouter.Params{
+		{Key: "id", Value: "42"},
+		{Key: "price", Value: "4.5"},
+		{Key: "uuid", Value: "8c5d7a5e-4b1a-4c3e-9f35-2f1b7c6d9e01"},
+		{Key: "slug", Value: "hello"},
+	}
+	i, err := c.PathParamInt("id")
+	if err != nil || i != 42 {
+		t.Errorf("failed testing path param int")
+	}
+	i64, err := c.PathParamInt64("id")
+	if err != nil || i64 != 42 {
+		t.Errorf("failed testing path param int64")
+	}
+	f, err := c.PathParamFloat("price")
+	if err != nil || f != 4.5 {
+		t.Errorf("failed testing path param float")
+	}
+	u, err := c.PathParamUUID("uuid")
+	if err != nil || u.String() != "8c5d7a5e-4b1a-4c3e-9f35-2f1b7c6d9e01" {
+		t.Errorf("failed testing path param uuid")
+	}
+	if _, err := c.PathParamInt("slug"); err == nil {
+		t.Errorf("expecting error for invalid int path param")
+	}
+	if _, err := c.PathParamUUID("slug"); err == nil {
+		t.Errorf("expecting error for invalid uuid path param")
+	}
+	if _, err := c.PathParamInt("missing"); err == nil {
+		t.Errorf("expecting error for missing path param")
+	}
+}
+
 func TestGetRequestParams(t *testing.T) {
 	pwd, _ := os.Getwd()
 	app := New()
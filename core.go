@@ -7,12 +7,14 @@ package core
 import (
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime/debug"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/gocondor/core/env"
@@ -41,18 +43,22 @@ type configContainer struct {
 }
 
 type App struct {
-	t           int // for trancking middlewares
-	chain       *chain
-	middlewares *Middlewares
-	Config      *configContainer
+	middlewares             *Middlewares
+	notFoundHandler         Handler
+	methodNotAllowedHandler Handler
+	errorHandler            ErrorHandler
+	trustedProxies          []*net.IPNet
+	Config                  *configContainer
 }
 
 var app *App
 
 func New() *App {
 	app = &App{
-		chain:       &chain{},
-		middlewares: NewMiddlewares(),
+		middlewares:             NewMiddlewares(),
+		notFoundHandler:         defaultNotFoundHandler,
+		methodNotAllowedHandler: defaultMethodNotAllowedHandler,
+		errorHandler:            defaultErrorHandler,
 		Config: &configContainer{
 			Request: requestC,
 		},
@@ -87,6 +93,10 @@ func (app *App) Run(router *httprouter.Router) {
 	useHttps, _ := strconv.ParseBool(useHttpsStr)
 
 	fmt.Printf("Welcome to GoCondor\n")
+	isDebugMode, _ := strconv.ParseBool(os.Getenv("APP_DEBUG_MODE"))
+	if isDebugMode {
+		fmt.Printf("Registered routes:\n%v\n", ResolveRouter().RoutesTable())
+	}
 	if useHttps {
 		fmt.Printf("Listening on https \nWaiting for requests...\n")
 	} else {
@@ -132,128 +142,203 @@ func (app *App) Run(router *httprouter.Router) {
 
 func (app *App) RegisterRoutes(routes []Route, router *httprouter.Router) *httprouter.Router {
 	router.PanicHandler = panicHandler
-	router.NotFound = notFoundHandler{}
-	router.MethodNotAllowed = methodNotAllowed{}
+	router.MethodNotAllowed = app.makeHTTPHandler(app.handleMethodNotAllowed)
+	var fallbacks []Route
+	var rts []Route
 	for _, route := range routes {
-		switch route.Method {
-		case GET:
-			router.GET(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
-		case POST:
-			router.POST(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
-		case DELETE:
-			router.DELETE(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
-		case PATCH:
-			router.PATCH(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
-		case PUT:
-			router.PUT(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
-		case OPTIONS:
-			router.OPTIONS(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
-		case HEAD:
-			router.HEAD(route.Path, app.makeHTTPRouterHandlerFunc(route.Handler, route.Middlewares))
+		if route.fallback {
+			fallbacks = append(fallbacks, route)
+			continue
+		}
+		rts = append(rts, route)
+	}
+	notFound := app.makeNotFoundHandler(fallbacks)
+	router.NotFound = notFound
+	router.GlobalOPTIONS = app.makeHTTPHandler(optionsHandler)
+	entries := buildRouteEntries(withHeadRoutes(rts))
+	for _, re := range entries {
+		switch re.method {
+		case GET, POST, DELETE, PATCH, PUT, OPTIONS, HEAD:
+			handle := app.makeDispatcherHandle(re, notFound)
+			router.Handle(strings.ToUpper(re.method), re.pattern, handle)
 		}
 	}
+	app.registerPreflightRoutes(entries, router)
 	return router
 }
 
-func (app *App) makeHTTPRouterHandlerFunc(h Handler, ms []Middleware) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-		ctx := &Context{
-			Request: &Request{
-				httpRequest:    r,
-				httpPathParams: ps,
-			},
-			Response: &Response{
-				headers:             []header{},
-				body:                nil,
-				contentType:         "",
-				overrideContentType: "",
-				HttpResponseWriter:  w,
-				isTerminated:        false,
-				redirectTo:          "",
-			},
-			GetValidator:     getValidator(),
-			GetJWT:           getJWT(),
-			GetGorm:          getGormFunc(),
-			GetCache:         resolveCache(),
-			GetHashing:       resloveHashing(),
-			GetMailer:        resolveMailer(),
-			GetEventsManager: resolveEventsManager(),
-			GetLogger:        resolveLogger(),
+// withHeadRoutes adds a HEAD route for each GET route that has no HEAD route, so the GET routes
+// answer HEAD requests too, the HEAD routes are folded together with the explicit ones
+func withHeadRoutes(routes []Route) []Route {
+	heads := map[string]bool{}
+	for _, route := range routes {
+		if route.Method == HEAD {
+			heads[route.Host+" "+route.Path+" "+route.version] = true
 		}
-		ctx.prepare(ctx)
-		rhs := app.combHandlers(h, ms)
-		app.prepareChain(rhs)
-		app.t = 0
-		app.chain.execute(ctx)
-		for _, header := range ctx.Response.headers {
-			w.Header().Add(header.key, header.val)
+	}
+	res := append([]Route{}, routes...)
+	for _, route := range routes {
+		if route.Method == GET && !heads[route.Host+" "+route.Path+" "+route.version] {
+			route.Method = HEAD
+			res = append(res, route)
 		}
-		logger.CloseLogsFile()
-		var ct string
-		if ctx.Response.overrideContentType != "" {
-			ct = ctx.Response.overrideContentType
-		} else if ctx.Response.contentType != "" {
-			ct = ctx.Response.contentType
-		} else {
-			ct = CONTENT_TYPE_HTML
+	}
+	return res
+}
+
+// makeHTTPHandler converts a handler to a net/http handler that runs the global middlewares
+func (app *App) makeHTTPHandler(h Handler) http.Handler {
+	handle := app.makeHTTPRouterHandlerFunc(h, nil)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, nil)
+	})
+}
+
+// optionsHandler answers the OPTIONS requests of the paths with no OPTIONS route,
+// the Allow header is set by the httprouter
+func optionsHandler(c *Context) *Response {
+	return c.Response.SetStatusCode(http.StatusNoContent)
+}
+
+// makeNotFoundHandler returns the handler of the requests that don't match any route,
+// the requests get dispatched to the fallback route with the longest matching prefix if any
+func (app *App) makeNotFoundHandler(fallbacks []Route) http.Handler {
+	var handles []httprouter.Handle
+	for _, route := range fallbacks {
+		handles = append(handles, app.makeRouteHandle(route))
+	}
+	notFound := app.makeHTTPHandler(app.handleNotFound)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := -1
+		var matchPrefix string
+		for i, route := range fallbacks {
+			if route.Method != strings.ToLower(r.Method) {
+				continue
+			}
+			prefix := route.Path[:strings.LastIndex(route.Path, "/*")]
+			if !strings.HasPrefix(r.URL.Path, prefix+"/") || (match != -1 && len(prefix) <= len(matchPrefix)) {
+				continue
+			}
+			match = i
+			matchPrefix = prefix
 		}
-		w.Header().Add(CONTENT_TYPE, ct)
-		if ctx.Response.statusCode != 0 {
-			w.WriteHeader(ctx.Response.statusCode)
+		if match == -1 {
+			notFound.ServeHTTP(w, r)
+			return
 		}
-		if ctx.Response.redirectTo != "" {
-			http.Redirect(w, r, ctx.Response.redirectTo, http.StatusPermanentRedirect)
-		} else {
-			w.Write(ctx.Response.body)
+		param := fallbacks[match].Path[strings.LastIndex(fallbacks[match].Path, "/*")+2:]
+		handles[match](w, r, httprouter.Params{{Key: param, Value: strings.TrimPrefix(r.URL.Path, matchPrefix)}})
+	})
+}
+
+// makeHTTPRouterHandlerFunc builds the chain of the handler once, the global middlewares
+// attached after the routes are registered don't run for the handler
+func (app *App) makeHTTPRouterHandlerFunc(h Handler, ms []Middleware) httprouter.Handle {
+	return app.makeChainHandle(app.prepareChain(app.combHandlers(h, ms)))
+}
+
+// makeRouteHandle builds the handle of a route with the middlewares resolved for it
+func (app *App) makeRouteHandle(route Route) httprouter.Handle {
+	return app.makeChainHandle(newChain(app.combHandlers(route.Handler, app.middlewares.resolve(route))))
+}
+
+func (app *App) makeChainHandle(cn *chain) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := acquireContext(app, w, r, ps)
+		ctx.prepare(ctx)
+		app.executeChain(ctx, cn)
+		logger.CloseLogsFile()
+		writeResponse(w, r, ctx.Response)
+		if ctx.events != nil {
+			ctx.events.processFiredEvents()
 		}
-		e := ResolveEventsManager()
-		if e != nil {
-			e.setContext(ctx).processFiredEvents()
+		if len(ctx.terminators) == 0 {
+			releaseContext(ctx)
+			return
 		}
-
-		app.t = 0
-		ctx.Response.reset()
-		app.chain.reset()
+		// the terminate hooks run after the handle returns, an http/2 response is
+		// finished only then, the context is released after the hooks are done
+		go func() {
+			ctx.terminate()
+			releaseContext(ctx)
+		}()
 	}
 }
 
-type notFoundHandler struct{}
-type methodNotAllowed struct{}
+func (app *App) executeChain(ctx *Context, cn *chain) {
+	defer func() {
+		if e := recover(); e != nil {
+			app.handleError(ctx, e)
+		}
+	}()
+	cn.execute(ctx)
+}
 
-func (n notFoundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotFound)
-	res := "{\"message\": \"Not Found\"}"
-	loggr.Error("Not Found")
-	loggr.Error(debug.Stack())
-	w.Header().Add(CONTENT_TYPE, CONTENT_TYPE_JSON)
-	w.Write([]byte(res))
+// setContentLength sets the Content-Length header of buffered responses, e.g. for the
+// HEAD responses, their body is not written so the length can't be counted from it
+func setContentLength(w http.ResponseWriter, r *http.Request, rs *Response) {
+	if rs.stream != nil || rs.redirectTo != "" || rs.GetHeader("Content-Length") != "" {
+		return
+	}
+	code := rs.GetStatusCode()
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(rs.body)))
 }
 
-func (n methodNotAllowed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusMethodNotAllowed)
-	res := "{\"message\": \"Method not allowed\"}"
-	loggr.Error("Method not allowed")
-	loggr.Error(debug.Stack())
-	w.Header().Add(CONTENT_TYPE, CONTENT_TYPE_JSON)
-	w.Write([]byte(res))
+// writeResponse writes the response's headers and body to the http response writer
+func writeResponse(w http.ResponseWriter, r *http.Request, rs *Response) {
+	for _, header := range rs.headers {
+		w.Header().Add(header.key, header.val)
+	}
+	if rs.stream != nil {
+		if rs.overrideContentType != "" {
+			w.Header().Set(CONTENT_TYPE, rs.overrideContentType)
+		}
+		rs.stream(w, r)
+		return
+	}
+	var ct string
+	if rs.overrideContentType != "" {
+		ct = rs.overrideContentType
+	} else if rs.contentType != "" {
+		ct = rs.contentType
+	} else {
+		ct = CONTENT_TYPE_HTML
+	}
+	w.Header().Add(CONTENT_TYPE, ct)
+	// the headers must be set before WriteHeader, the body of the HEAD response is not
+	// written, so its Content-Length is the length of the body the GET response has
+	if r.Method == http.MethodHead {
+		setContentLength(w, r, rs)
+	}
+	if rs.statusCode != 0 {
+		w.WriteHeader(rs.statusCode)
+	}
+	if rs.redirectTo != "" {
+		http.Redirect(w, r, rs.redirectTo, http.StatusPermanentRedirect)
+	} else if r.Method != http.MethodHead {
+		w.Write(rs.body)
+	}
 }
 
+// panicHandler handles the panics that happen outside of the middlewares and the handler,
+// e.g. in the error handler, the ones inside are handled by the app's error handler
 var panicHandler = func(w http.ResponseWriter, r *http.Request, e interface{}) {
 	isDebugModeStr := os.Getenv("APP_DEBUG_MODE")
 	isDebugMode, err := strconv.ParseBool(isDebugModeStr)
 	if err != nil {
 		errStr := "error parsing env var APP_DEBUG_MODE"
 		loggr.Error(errStr)
-		fmt.Sprintln(errStr)
 		w.Write([]byte(errStr))
 		return
 	}
 	if !isDebugMode {
 		errStr := "internal error"
 		loggr.Error(errStr)
-		fmt.Sprintln(errStr)
-		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Add(CONTENT_TYPE, CONTENT_TYPE_JSON)
+		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(fmt.Sprintf("{\"message\": \"%v\"}", errStr)))
 		return
 	}
@@ -267,8 +352,8 @@ var panicHandler = func(w http.ResponseWriter, r *http.Request, e interface{}) {
 	} else {
 		res = fmt.Sprintf("{\"message\": \"%v\", \"stack trace\": \"%v\"}", e, string(debug.Stack()))
 	}
-	w.WriteHeader(http.StatusInternalServerError)
 	w.Header().Add(CONTENT_TYPE, CONTENT_TYPE_JSON)
+	w.WriteHeader(http.StatusInternalServerError)
 	w.Write([]byte(res))
 }
 
@@ -276,62 +361,85 @@ func UseMiddleware(mw Middleware) {
 	ResolveMiddlewares().Attach(mw)
 }
 
+// RegisterMiddleware registers the middleware under the given name, e.g. "csrf", the name is used
+// to add it to routes and middleware groups, to skip it for a route or to set its priority
+func (app *App) RegisterMiddleware(name string, mw Middleware) {
+	app.middlewares.Alias(name, mw)
+}
+
+// UseNamedMiddlewares attaches the middlewares registered under the given names as global middlewares
+func (app *App) UseNamedMiddlewares(names ...string) {
+	app.middlewares.AttachByName(names...)
+}
+
+// RegisterMiddlewareGroup registers a named group of the middlewares registered under the given names,
+// e.g. "web" or "api", routes use the group through Router.WithMiddlewareGroups() or Router.Middleware()
+func (app *App) RegisterMiddlewareGroup(name string, names ...string) {
+	app.middlewares.AttachGroup(name, names...)
+}
+
+// SetMiddlewarePriority sets the order the middlewares registered under the given names run in relative to each other
+func (app *App) SetMiddlewarePriority(names ...string) {
+	app.middlewares.SetPriority(names...)
+}
+
+// Next runs the next middleware or the handler in the chain of the given request
 func (app *App) Next(c *Context) {
-	app.t = app.t + 1
-	n := app.chain.getByIndex(app.t)
-	if n != nil {
-		f, ok := n.(Middleware)
-		if ok {
-			f(c)
-		} else {
-			ff, ok := n.(Handler)
-			if ok {
-				ff(c)
-			}
-		}
-	}
+	c.Next()
 }
 
+// chain holds the global middlewares, the route's middlewares and the handler of a route in the order they run
 type chain struct {
-	nodes []interface{}
+	nodes []Middleware
 }
 
 func (cn *chain) reset() {
-	cn.nodes = []interface{}{}
+	cn.nodes = []Middleware{}
 }
 
-func (c *chain) getByIndex(i int) interface{} {
-	for k := range c.nodes {
-		if k == i {
-			return c.nodes[i]
-		}
+func (cn *chain) getByIndex(i int) Middleware {
+	if i < 0 || i >= len(cn.nodes) {
+		return nil
 	}
+	return cn.nodes[i]
+}
 
-	return nil
+// prepareChain builds a chain from the global middlewares and the given handlers
+func (app *App) prepareChain(hs []interface{}) *chain {
+	var nodes []interface{}
+	for _, mw := range app.middlewares.GetMiddlewares() {
+		nodes = append(nodes, mw)
+	}
+	return newChain(append(nodes, hs...))
 }
 
-func (app *App) prepareChain(hs []interface{}) {
-	mw := app.middlewares.GetMiddlewares()
-	for _, v := range mw {
-		app.chain.nodes = append(app.chain.nodes, v)
+// newChain builds a chain from the given handlers, the handler
+// is converted to a node that ignores its returned response
+func newChain(hs []interface{}) *chain {
+	cn := &chain{
+		nodes: make([]Middleware, 0, len(hs)),
 	}
 	for _, v := range hs {
-		app.chain.nodes = append(app.chain.nodes, v)
+		switch n := v.(type) {
+		case Middleware:
+			cn.nodes = append(cn.nodes, n)
+		case Handler:
+			cn.nodes = append(cn.nodes, func(c *Context) { n(c) })
+		}
 	}
+	return cn
 }
 
+// execute runs the first node of the chain, the nodes after it run through ctx.Next()
 func (cn *chain) execute(ctx *Context) {
-	i := cn.getByIndex(0)
-	if i != nil {
-		f, ok := i.(Middleware)
-		if ok {
-			f(ctx)
-		} else {
-			ff, ok := i.(Handler)
-			if ok {
-				ff(ctx)
-			}
-		}
+	ctx.chain = cn
+	ctx.t = 0
+	cn.run(0, ctx)
+}
+
+func (cn *chain) run(i int, ctx *Context) {
+	if i < len(cn.nodes) {
+		cn.nodes[i](ctx)
 	}
 }
 
@@ -493,16 +601,24 @@ func resolveMailer() func() *Mailer {
 	return f
 }
 
-func resolveEventsManager() func() *EventsManager {
+func resolveEventsManager(c *Context) func() *EventsManager {
 	f := func() *EventsManager {
-		return ResolveEventsManager()
+		return c.events
 	}
 	return f
 }
 
-func resolveLogger() func() *logger.Logger {
+// resolveLogger returns the logger of the request, its lines are prefixed
+// with the request id if the request id middleware runs for the request
+func resolveLogger(c *Context) func() *logger.Logger {
 	f := func() *logger.Logger {
-		return loggr
+		if c.requestID == "" || loggr == nil {
+			return loggr
+		}
+		if c.logger == nil {
+			c.logger = loggr.WithPrefix(c.requestID)
+		}
+		return c.logger
 	}
 	return f
 }
@@ -0,0 +1,268 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type CORSOptions struct {
+	// the origins allowed to make requests, an origin can have wildcards like https://*.example.com,
+	// "*" allows all origins, default is "*"
+	AllowedOrigins []string
+	// regex patterns of the allowed origins, e.g. ^https://[a-z]+\.example\.com$
+	AllowedOriginPatterns []string
+	// decides whether the origin is allowed, it's checked after the allowed origins and patterns
+	AllowOriginFunc func(origin string) bool
+	// the methods allowed in cross origin requests, default is the methods the requested path supports
+	AllowedMethods []string
+	// the headers allowed in cross origin requests, default is the headers the preflight request asks for
+	AllowedHeaders []string
+	// the response headers the browser exposes to the client script
+	ExposedHeaders []string
+	// allows the requests with cookies and http authentication, the allowed origins must be listed,
+	// allowing all origins with credentials would let any site make authenticated requests
+	AllowCredentials bool
+	// the number of seconds the browser caches the preflight response for
+	MaxAge int
+}
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type cors struct {
+	opts           CORSOptions
+	allowAll       bool
+	origins        map[string]bool
+	originPatterns []*regexp.Regexp
+	allowedHeaders map[string]bool
+}
+
+// CORS returns a middleware that handles cross origin requests, the preflight requests are answered
+// by the middleware, it can be attached globally or to the routes that need it
+func CORS(opts ...CORSOptions) Middleware {
+	var opt CORSOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if len(opt.AllowedOrigins) == 0 && len(opt.AllowedOriginPatterns) == 0 && opt.AllowOriginFunc == nil {
+		opt.AllowedOrigins = []string{"*"}
+	}
+	cs := &cors{
+		opts:           opt,
+		origins:        map[string]bool{},
+		allowedHeaders: map[string]bool{},
+	}
+	for _, origin := range opt.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			cs.allowAll = true
+			continue
+		}
+		if !strings.Contains(origin, "*") {
+			cs.origins[origin] = true
+			continue
+		}
+		pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(origin), "\\*", "[^/]+") + "$"
+		cs.originPatterns = append(cs.originPatterns, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range opt.AllowedOriginPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			panic(fmt.Sprintf("invalid cors origin pattern %v: %v", pattern, err))
+		}
+		cs.originPatterns = append(cs.originPatterns, re)
+	}
+	if cs.allowAll && opt.AllowCredentials {
+		panic("cors can't allow credentials for all origins, list the allowed origins or patterns")
+	}
+	for _, h := range opt.AllowedHeaders {
+		cs.allowedHeaders[strings.ToLower(h)] = true
+	}
+	return cs.handle
+}
+
+// isCORSMiddleware checks whether the middleware is created by CORS(), the paths of the routes
+// that use one get an OPTIONS route so their preflight requests reach it, the middlewares
+// created by CORS() are method values of the cors type, they all share its code pointer
+func isCORSMiddleware(mw Middleware) bool {
+	return reflect.ValueOf(mw).Pointer() == reflect.ValueOf(Middleware((&cors{}).handle)).Pointer()
+}
+
+// registerPreflightRoutes adds an OPTIONS route for the paths with no OPTIONS route whose routes
+// use a cors middleware that is not global, the global middlewares run for the OPTIONS requests
+// of all paths, the ones attached to routes don't, the preflight request is handled by the cors
+// middlewares of the route of the requested method, the Allow header is set like the httprouter does
+func (app *App) registerPreflightRoutes(entries []*routeEntry, router *httprouter.Router) {
+	methods := map[string][]string{}
+	corsMws := map[string]map[string][]Middleware{}
+	var patterns []string
+	for _, re := range entries {
+		if _, ok := methods[re.pattern]; !ok {
+			patterns = append(patterns, re.pattern)
+		}
+		method := strings.ToUpper(re.method)
+		methods[re.pattern] = append(methods[re.pattern], method)
+		for _, c := range re.candidates {
+			var mws []Middleware
+			for _, nm := range app.middlewares.resolveNamed(c.route) {
+				if !nm.global && isCORSMiddleware(nm.mw) {
+					mws = append(mws, nm.mw)
+				}
+			}
+			if len(mws) == 0 || corsMws[re.pattern][method] != nil {
+				continue
+			}
+			if corsMws[re.pattern] == nil {
+				corsMws[re.pattern] = map[string][]Middleware{}
+			}
+			corsMws[re.pattern][method] = mws
+		}
+	}
+	for _, pattern := range patterns {
+		if len(corsMws[pattern]) == 0 || containsMethod(methods[pattern], http.MethodOptions) {
+			continue
+		}
+		allowed := append(methods[pattern], http.MethodOptions)
+		if containsMethod(allowed, http.MethodGet) && !containsMethod(allowed, http.MethodHead) {
+			allowed = append(allowed, http.MethodHead)
+		}
+		sort.Strings(allowed)
+		allow := strings.Join(allowed, ", ")
+		handles := map[string]httprouter.Handle{}
+		var first httprouter.Handle
+		for _, method := range allowed {
+			if mws, ok := corsMws[pattern][method]; ok {
+				handles[method] = app.makeHTTPRouterHandlerFunc(optionsHandler, mws)
+				if first == nil {
+					first = handles[method]
+				}
+			}
+		}
+		router.Handle(http.MethodOptions, pattern, func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			w.Header().Set("Allow", allow)
+			handle, ok := handles[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))]
+			if !ok {
+				handle = first
+			}
+			handle(w, r, ps)
+		})
+	}
+}
+
+func (cs *cors) handle(c *Context) {
+	r := c.Request.httpRequest
+	origin := r.Header.Get("Origin")
+	reqMethod := r.Header.Get("Access-Control-Request-Method")
+	if origin != "" && r.Method == http.MethodOptions && reqMethod != "" {
+		cs.preflight(c, origin, reqMethod)
+		return
+	}
+	// the response of a request without an origin is varied by the origin too unless all origins
+	// are allowed, so the caches don't serve it to the cross origin requests without the cors headers
+	if origin != "" || !cs.allowAll {
+		c.Response.SetHeader("Vary", "Origin")
+	}
+	if origin == "" {
+		c.Next()
+		return
+	}
+	if cs.allowOrigin(origin) {
+		cs.setOriginHeaders(c, origin)
+		if len(cs.opts.ExposedHeaders) != 0 {
+			c.Response.SetHeader("Access-Control-Expose-Headers", strings.Join(cs.opts.ExposedHeaders, ", "))
+		}
+	}
+	c.Next()
+}
+
+// preflight answers the preflight request, the requests for methods the path doesn't
+// support get the response of the app's method not allowed handler
+func (cs *cors) preflight(c *Context, origin string, reqMethod string) {
+	c.Response.SetHeader("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+	if !cs.allowOrigin(origin) {
+		c.Next()
+		return
+	}
+	methods := cs.opts.AllowedMethods
+	allow := c.Response.HttpResponseWriter.Header().Get("Allow")
+	if len(methods) == 0 && allow != "" {
+		methods = strings.Split(allow, ", ")
+	}
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	if !containsMethod(methods, reqMethod) {
+		if c.app != nil {
+			c.app.handleMethodNotAllowed(c)
+			return
+		}
+		defaultMethodNotAllowedHandler(c)
+		return
+	}
+	reqHeaders := c.Request.httpRequest.Header.Get("Access-Control-Request-Headers")
+	if reqHeaders != "" && len(cs.allowedHeaders) != 0 {
+		for _, h := range strings.Split(reqHeaders, ",") {
+			if !cs.allowedHeaders[strings.ToLower(strings.TrimSpace(h))] {
+				c.Response.SetStatusCode(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	cs.setOriginHeaders(c, origin)
+	c.Response.SetHeader("Access-Control-Allow-Methods", strings.ToUpper(strings.Join(methods, ", ")))
+	if len(cs.opts.AllowedHeaders) != 0 {
+		c.Response.SetHeader("Access-Control-Allow-Headers", strings.Join(cs.opts.AllowedHeaders, ", "))
+	} else if reqHeaders != "" {
+		c.Response.SetHeader("Access-Control-Allow-Headers", reqHeaders)
+	}
+	if cs.opts.MaxAge > 0 {
+		c.Response.SetHeader("Access-Control-Max-Age", strconv.Itoa(cs.opts.MaxAge))
+	}
+	c.Response.SetStatusCode(http.StatusNoContent)
+}
+
+func (cs *cors) setOriginHeaders(c *Context, origin string) {
+	if cs.allowAll {
+		c.Response.SetHeader("Access-Control-Allow-Origin", "*")
+	} else {
+		c.Response.SetHeader("Access-Control-Allow-Origin", origin)
+	}
+	if cs.opts.AllowCredentials {
+		c.Response.SetHeader("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (cs *cors) allowOrigin(origin string) bool {
+	if cs.allowAll {
+		return true
+	}
+	o := strings.ToLower(origin)
+	if cs.origins[o] {
+		return true
+	}
+	for _, re := range cs.originPatterns {
+		if re.MatchString(o) {
+			return true
+		}
+	}
+	return cs.opts.AllowOriginFunc != nil && cs.opts.AllowOriginFunc(origin)
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(strings.TrimSpace(m), method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestCORS(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	UseMiddleware(CORS(CORSOptions{
+		AllowedOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []string{`^https://[a-z]+\.example\.net$`},
+		AllowedHeaders:        []string{"Content-Type", "Authorization"},
+		ExposedHeaders:        []string{"X-Total"},
+		AllowCredentials:      true,
+		MaxAge:                600,
+	}))
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response { return c.Response.Json("[]") }))
+	r.Post("/users", Handler(func(c *Context) *Response { return c.Response.Json("{}") }))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	serve := func(method string, origin string, headers map[string]string) *http.Response {
+		req := httptest.NewRequest(method, "/users", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for key, val := range headers {
+			req.Header.Set(key, val)
+		}
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	rsp := serve("OPTIONS", "https://app.example.com", map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type"})
+	h := rsp.Header
+	if rsp.StatusCode != http.StatusNoContent || h.Get("Access-Control-Allow-Origin") != "https://app.example.com" || h.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("failed answering the preflight request, found %v %v", rsp.StatusCode, h)
+	}
+	if h.Get("Access-Control-Allow-Methods") != "GET, HEAD, OPTIONS, POST" || h.Get("Access-Control-Allow-Headers") != "Content-Type, Authorization" || h.Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("failed setting the preflight headers, found %v", h)
+	}
+
+	rsp = serve("OPTIONS", "https://api.example.org", map[string]string{"Access-Control-Request-Method": "DELETE"})
+	if rsp.StatusCode != http.StatusMethodNotAllowed || rsp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected the preflight request of an unsupported method to get 405, found %v", rsp.StatusCode)
+	}
+
+	rsp = serve("OPTIONS", "https://shop.example.net", map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Custom"})
+	if rsp.StatusCode != http.StatusNoContent || rsp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected the preflight request with a header that's not allowed to get no cors headers")
+	}
+
+	rsp = serve("GET", "https://evil.com", nil)
+	if rsp.StatusCode != http.StatusOK || rsp.Header.Get("Access-Control-Allow-Origin") != "" || rsp.Header.Get("Vary") != "Origin" {
+		t.Errorf("expected no cors headers for a disallowed origin, found %v", rsp.Header)
+	}
+
+	rsp = serve("GET", "https://shop.example.org", nil)
+	if rsp.Header.Get("Access-Control-Allow-Origin") != "https://shop.example.org" || rsp.Header.Get("Access-Control-Expose-Headers") != "X-Total" {
+		t.Errorf("failed setting the cors headers of the request, found %v", rsp.Header)
+	}
+
+	rsp = serve("PUT", "https://app.example.com", nil)
+	if rsp.StatusCode != http.StatusMethodNotAllowed || rsp.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("expected the 405 response to have the cors headers, found %v %v", rsp.StatusCode, rsp.Header)
+	}
+
+	rsp = serve("GET", "", nil)
+	if rsp.Header.Get("Access-Control-Allow-Origin") != "" || len(rsp.Header.Values("Vary")) != 1 || rsp.Header.Get("Vary") != "Origin" {
+		t.Errorf("expected no cors headers but the vary header for a same origin request, found %v", rsp.Header)
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	UseMiddleware(CORS())
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response { return c.Response.Json("[]") }))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	req := httptest.NewRequest("OPTIONS", "/users", nil)
+	req.Header.Set("Origin", "https://any.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, req)
+	h := w.Result().Header
+	if h.Get("Access-Control-Allow-Origin") != "*" || h.Get("Access-Control-Allow-Headers") != "X-Custom" || h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("failed allowing all origins, found %v", h)
+	}
+}
+
+func TestCORSRouteLevel(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	cors := CORS(CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response { return c.Response.Json("[]") }), cors)
+	r.Post("/users", Handler(func(c *Context) *Response { return c.Response.Json("{}") }), cors)
+	r.Get("/private", Handler(func(c *Context) *Response { return c.Response.Json("{}") }))
+	if !isCORSMiddleware(cors) || isCORSMiddleware(ETag()) || isCORSMiddleware(func(c *Context) {}) {
+		t.Errorf("failed telling apart the cors middlewares")
+	}
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	preflight := func(path string) *http.Response {
+		req := httptest.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w.Result()
+	}
+	rsp := preflight("/users")
+	h := rsp.Header
+	if rsp.StatusCode != http.StatusNoContent || h.Get("Access-Control-Allow-Origin") != "https://app.example.com" || h.Get("Access-Control-Allow-Methods") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("failed answering the preflight request of a route level cors middleware, found %v %v", rsp.StatusCode, h)
+	}
+	if h.Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("failed setting the Allow header of the preflight route, found %v", h.Get("Allow"))
+	}
+	rsp = preflight("/private")
+	if rsp.Header.Get("Access-Control-Allow-Origin") != "" || rsp.Header.Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected no cors headers for a route without the cors middleware, found %v", rsp.Header)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected allowing credentials for all origins to panic")
+		}
+	}()
+	CORS(CORSOptions{AllowCredentials: true})
+}
@@ -0,0 +1,298 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const csrfTokenLength = 32
+
+type CSRFOptions struct {
+	// the key the tokens are signed with, default is the CSRF_SECRET env var, if it's
+	// not set a random key is generated, so the tokens don't survive restarts
+	Secret []byte
+	// stores the token of the client, default is a cookie (signed double submit cookie),
+	// a store backed by the session can be used instead
+	Store CSRFStore
+	// the name of the cookie of the default store, default is csrf_token
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	// sends the cookie over https only
+	CookieSecure bool
+	// default is http.SameSiteLaxMode
+	CookieSameSite http.SameSite
+	// the lifetime of the cookie, default is 12 hours
+	MaxAge time.Duration
+	// the name of the form field the token is submitted in, default is _csrf_token
+	FieldName string
+	// the name of the header the token is submitted in for ajax requests, default is X-CSRF-Token
+	HeaderName string
+	// the origins allowed to submit requests other than the app's own, e.g. https://admin.example.com
+	TrustedOrigins []string
+	// the paths that are not checked, a path ending with * matches all the paths with its prefix,
+	// single routes can be exempted with Router.WithoutMiddleware() too if it's registered by name
+	ExemptPaths []string
+}
+
+// CSRFStore stores the csrf token of a client
+type CSRFStore interface {
+	// Get returns the stored token, or an empty string if no token is stored
+	Get(c *Context) (string, error)
+	Save(c *Context, token string) error
+}
+
+type csrf struct {
+	opts   CSRFOptions
+	secret []byte
+}
+
+// CSRF returns a middleware that protects the requests with unsafe methods against cross site request
+// forgery, the requests must submit the token from c.CSRFToken() in the form field or the header, and
+// their Origin or Referer header must match the app's host or a trusted origin, the requests that fail
+// get a 403 response through the app's error handler
+func CSRF(opts ...CSRFOptions) Middleware {
+	var opt CSRFOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	secret := opt.Secret
+	if len(secret) == 0 {
+		secret = []byte(os.Getenv("CSRF_SECRET"))
+	}
+	if len(secret) == 0 {
+		secret = randomBytes(32)
+	}
+	if opt.CookieName == "" {
+		opt.CookieName = "csrf_token"
+	}
+	if opt.CookiePath == "" {
+		opt.CookiePath = "/"
+	}
+	if opt.CookieSameSite == 0 {
+		opt.CookieSameSite = http.SameSiteLaxMode
+	}
+	if opt.MaxAge == 0 {
+		opt.MaxAge = 12 * time.Hour
+	}
+	if opt.FieldName == "" {
+		opt.FieldName = "_csrf_token"
+	}
+	if opt.HeaderName == "" {
+		opt.HeaderName = "X-CSRF-Token"
+	}
+	if opt.Store == nil {
+		opt.Store = &cookieCSRFStore{opts: opt}
+	}
+	cs := &csrf{
+		opts:   opt,
+		secret: secret,
+	}
+	return cs.handle
+}
+
+func (cs *csrf) handle(c *Context) {
+	r := c.Request.httpRequest
+	c.csrfField = cs.opts.FieldName
+	token := cs.storedToken(c)
+	if token == nil {
+		token = randomBytes(csrfTokenLength)
+		err := cs.opts.Store.Save(c, cs.sign(token))
+		if err != nil {
+			panic(fmt.Sprintf("error saving the csrf token: %v", err))
+		}
+	}
+	c.csrfToken = token
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		c.Next()
+		return
+	}
+	if cs.exempt(r.URL.Path) {
+		c.Next()
+		return
+	}
+	if msg := cs.checkOrigin(c); msg != "" {
+		c.handleError(NewHTTPError(http.StatusForbidden, msg))
+		return
+	}
+	submitted := r.Header.Get(cs.opts.HeaderName)
+	if submitted == "" {
+		submitted = r.FormValue(cs.opts.FieldName)
+	}
+	if !validMaskedToken(submitted, token) {
+		c.handleError(NewHTTPError(http.StatusForbidden, "CSRF token mismatch"))
+		return
+	}
+	c.Next()
+}
+
+// storedToken returns the token of the client if it's stored and its signature is valid
+func (cs *csrf) storedToken(c *Context) []byte {
+	v, err := cs.opts.Store.Get(c)
+	if err != nil || v == "" {
+		return nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil || len(b) != csrfTokenLength+sha256.Size {
+		return nil
+	}
+	token := b[:csrfTokenLength]
+	if !hmac.Equal(b[csrfTokenLength:], cs.mac(token)) {
+		return nil
+	}
+	return token
+}
+
+func (cs *csrf) sign(token []byte) string {
+	return base64.RawURLEncoding.EncodeToString(append(append([]byte{}, token...), cs.mac(token)...))
+}
+
+func (cs *csrf) mac(token []byte) []byte {
+	m := hmac.New(sha256.New, cs.secret)
+	m.Write(token)
+	return m.Sum(nil)
+}
+
+func (cs *csrf) exempt(path string) bool {
+	for _, p := range cs.opts.ExemptPaths {
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(path, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin checks that the request is sent from the app's host or a trusted origin, the Origin
+// header is checked if it's sent, otherwise the Referer header is required for https requests,
+// the requests received from the trusted proxies of the app are checked against the forwarded host
+func (cs *csrf) checkOrigin(c *Context) string {
+	r := c.Request.httpRequest
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			if c.IsHTTPS() {
+				return "missing referer"
+			}
+			return ""
+		}
+		u, err := url.Parse(referer)
+		if err != nil || u.Host == "" {
+			return "invalid referer"
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return "invalid origin"
+	}
+	if strings.EqualFold(u.Host, c.host()) {
+		return ""
+	}
+	for _, trusted := range cs.opts.TrustedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(trusted, "/"), u.Scheme+"://"+u.Host) {
+			return ""
+		}
+	}
+	return "origin not allowed"
+}
+
+// CSRFToken returns the csrf token to submit with the forms and the ajax requests, the token is
+// masked differently on each call so it can't be extracted from compressed responses, it's
+// empty if the csrf middleware doesn't run for the request
+func (c *Context) CSRFToken() string {
+	c.checkReleased()
+	if c.csrfToken == nil {
+		return ""
+	}
+	return maskToken(c.csrfToken)
+}
+
+// CSRFField returns a hidden form input with the csrf token
+func (c *Context) CSRFField() string {
+	token := c.CSRFToken()
+	if token == "" {
+		return ""
+	}
+	return fmt.Sprintf("<input type=\"hidden\" name=\"%v\" value=\"%v\">", html.EscapeString(c.csrfField), token)
+}
+
+// maskToken xors the token with a random pad and prepends the pad
+func maskToken(token []byte) string {
+	pad := randomBytes(len(token))
+	masked := make([]byte, len(token)*2)
+	copy(masked, pad)
+	for i := range token {
+		masked[len(token)+i] = token[i] ^ pad[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func validMaskedToken(masked string, token []byte) bool {
+	b, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(b) != len(token)*2 {
+		return false
+	}
+	unmasked := make([]byte, len(token))
+	for i := range token {
+		unmasked[i] = b[i] ^ b[len(token)+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, token) == 1
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("error generating random bytes: %v", err))
+	}
+	return b
+}
+
+// cookieCSRFStore stores the signed token in a cookie
+type cookieCSRFStore struct {
+	opts CSRFOptions
+}
+
+func (s *cookieCSRFStore) Get(c *Context) (string, error) {
+	cookie, err := c.Request.httpRequest.Cookie(s.opts.CookieName)
+	if err == http.ErrNoCookie {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+func (s *cookieCSRFStore) Save(c *Context, token string) error {
+	cookie := &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    token,
+		Path:     s.opts.CookiePath,
+		Domain:   s.opts.CookieDomain,
+		MaxAge:   int(s.opts.MaxAge.Seconds()),
+		Secure:   s.opts.CookieSecure,
+		HttpOnly: true,
+		SameSite: s.opts.CookieSameSite,
+	}
+	c.Response.SetHeader("Set-Cookie", cookie.String())
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestCSRF(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	mw := CSRF(CSRFOptions{
+		Secret:         []byte("secret"),
+		TrustedOrigins: []string{"https://admin.example.com"},
+		ExemptPaths:    []string{"/hooks/*"},
+	})
+	app.RegisterMiddleware("csrf", mw)
+	app.UseNamedMiddlewares("csrf")
+	h := Handler(func(c *Context) *Response { return c.Response.Text(c.CSRFToken()) })
+	r := NewRouter()
+	r.Get("/form", h)
+	r.Post("/form", h)
+	r.Post("/hooks/github", h)
+	r.Post("/callback", h).WithoutMiddleware("csrf")
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	serve := func(req *http.Request) *http.Response {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	rsp := serve(httptest.NewRequest("GET", "/form", nil))
+	cookies := rsp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf_token" || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("failed setting the csrf cookie, found %v", rsp.Header)
+	}
+	cookie := cookies[0]
+	body := readBody(t, rsp)
+	if body == "" {
+		t.Fatalf("failed getting the csrf token")
+	}
+
+	req := httptest.NewRequest("GET", "/form", nil)
+	req.AddCookie(cookie)
+	rsp = serve(req)
+	token := readBody(t, rsp)
+	if len(rsp.Cookies()) != 0 {
+		t.Errorf("expected the valid csrf cookie to be reused")
+	}
+	if token == body {
+		t.Errorf("expected the csrf token to be masked differently on each request")
+	}
+
+	post := func(token string, headers map[string]string) *http.Response {
+		form := url.Values{"_csrf_token": {token}}
+		req := httptest.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookie)
+		for key, val := range headers {
+			req.Header.Set(key, val)
+		}
+		return serve(req)
+	}
+	if rsp = post(token, nil); rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the form with the csrf token to pass, found %v", rsp.StatusCode)
+	}
+	if rsp = post(body, map[string]string{"Origin": "https://admin.example.com"}); rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the request from a trusted origin to pass, found %v", rsp.StatusCode)
+	}
+	if rsp = post("", map[string]string{"X-CSRF-Token": token, "Referer": "http://example.com/form"}); rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the request with the csrf header to pass, found %v", rsp.StatusCode)
+	}
+	if rsp = post("", nil); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the form without the csrf token to get 403, found %v", rsp.StatusCode)
+	}
+	if rsp = post(maskToken(randomBytes(csrfTokenLength)), nil); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the form with a wrong csrf token to get 403, found %v", rsp.StatusCode)
+	}
+	if rsp = post(token, map[string]string{"Origin": "https://evil.com"}); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the request from another origin to get 403, found %v", rsp.StatusCode)
+	}
+	if rsp = post(token, map[string]string{"Referer": "https://evil.com/form"}); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the request referred by another origin to get 403, found %v", rsp.StatusCode)
+	}
+
+	// the forwarded host and scheme are used only for the requests received from a trusted proxy
+	forwarded := map[string]string{"Origin": "https://app.example.org", "X-Forwarded-Host": "app.example.org"}
+	if rsp = post(token, forwarded); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the forwarded host of an untrusted client to be ignored, found %v", rsp.StatusCode)
+	}
+	if rsp = post(token, map[string]string{"X-Forwarded-Proto": "https"}); rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the forwarded scheme of an untrusted client to be ignored, found %v", rsp.StatusCode)
+	}
+	app.SetTrustedProxies("192.0.2.1")
+	if rsp = post(token, forwarded); rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the request to the forwarded host to pass, found %v", rsp.StatusCode)
+	}
+	if rsp = post(token, map[string]string{"X-Forwarded-Proto": "https"}); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the https request through a proxy without a referer to get 403, found %v", rsp.StatusCode)
+	}
+	app.SetTrustedProxies()
+
+	// a cookie signed with another secret is rejected
+	forged := CSRF(CSRFOptions{Secret: []byte("other")})
+	c := acquireContext(nil, httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	c.chain = newChain([]interface{}{h})
+	forged(c)
+	forgedToken := c.CSRFToken()
+	forgedCookie := readCookie(c.Response)
+	releaseContext(c)
+	form := url.Values{"_csrf_token": {forgedToken}}
+	req = httptest.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(forgedCookie)
+	if rsp = serve(req); rsp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the cookie signed with another secret to get 403, found %v", rsp.StatusCode)
+	}
+
+	for _, path := range []string{"/hooks/github", "/callback"} {
+		if rsp = serve(httptest.NewRequest("POST", path, nil)); rsp.StatusCode != http.StatusOK {
+			t.Errorf("expected the exempted path %v to pass, found %v", path, rsp.StatusCode)
+		}
+	}
+}
+
+func readBody(t *testing.T, rsp *http.Response) string {
+	t.Helper()
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatalf("failed reading the response body: %v", err)
+	}
+	return string(b)
+}
+
+func readCookie(rs *Response) *http.Cookie {
+	header := http.Header{}
+	header.Add("Set-Cookie", rs.GetHeader("Set-Cookie"))
+	return (&http.Response{Header: header}).Cookies()[0]
+}
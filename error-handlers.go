@@ -0,0 +1,132 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/gocondor/core/env"
+)
+
+// ErrorHandler handles the panics that happen while handling a request, the
+// response of the request is reset before the handler is called except for the headers
+type ErrorHandler func(c *Context, err interface{}) *Response
+
+// HTTPError is an error with an http status code, when it's passed to the error handler
+// the status code and the message are used in the response
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func NewHTTPError(statusCode int, message string) *HTTPError {
+	return &HTTPError{
+		StatusCode: statusCode,
+		Message:    message,
+	}
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// SetNotFoundHandler sets the handler of the requests that don't match any route
+func (app *App) SetNotFoundHandler(h Handler) {
+	app.notFoundHandler = h
+}
+
+// SetMethodNotAllowedHandler sets the handler of the requests with a method the matched path doesn't support,
+// the Allow header is set before the handler is called
+func (app *App) SetMethodNotAllowedHandler(h Handler) {
+	app.methodNotAllowedHandler = h
+}
+
+// SetErrorHandler sets the handler of the panics that happen while handling a request
+func (app *App) SetErrorHandler(h ErrorHandler) {
+	app.errorHandler = h
+}
+
+func (app *App) handleNotFound(c *Context) *Response {
+	return app.notFoundHandler(c)
+}
+
+func (app *App) handleMethodNotAllowed(c *Context) *Response {
+	return app.methodNotAllowedHandler(c)
+}
+
+func (app *App) handleError(c *Context, err interface{}) *Response {
+	c.Response.reset()
+	return app.errorHandler(c, err)
+}
+
+// handleError responds with the app's error handler, or with the default one if the context has no app
+func (c *Context) handleError(err interface{}) *Response {
+	if c.app != nil {
+		return c.app.handleError(c, err)
+	}
+	c.Response.reset()
+	return defaultErrorHandler(c, err)
+}
+
+func defaultNotFoundHandler(c *Context) *Response {
+	return errorResponse(c, http.StatusNotFound, "Not Found", "")
+}
+
+func defaultMethodNotAllowedHandler(c *Context) *Response {
+	return errorResponse(c, http.StatusMethodNotAllowed, "Method not allowed", "")
+}
+
+func defaultErrorHandler(c *Context, err interface{}) *Response {
+	if e, ok := err.(*HTTPError); ok {
+		return errorResponse(c, e.StatusCode, e.Message, "")
+	}
+	stack := string(debug.Stack())
+	c.requestLogger().Error(fmt.Sprintf("%v", err))
+	c.requestLogger().Error(stack)
+	isDebugMode, _ := strconv.ParseBool(os.Getenv("APP_DEBUG_MODE"))
+	if !isDebugMode || env.GetVarOtherwiseDefault("APP_ENV", "local") == PRODUCTION {
+		return errorResponse(c, http.StatusInternalServerError, "internal error", "")
+	}
+	return errorResponse(c, http.StatusInternalServerError, fmt.Sprintf("%v", err), stack)
+}
+
+// errorResponse responds with html if the client accepts html, otherwise with json
+func errorResponse(c *Context, statusCode int, message string, stack string) *Response {
+	c.Response.SetStatusCode(statusCode)
+	if acceptsHTML(c.GetHeader("Accept")) {
+		body := fmt.Sprintf("<!doctype html>\n<title>%v %v</title>\n<h1>%v %v</h1>\n<p>%v</p>\n", statusCode, http.StatusText(statusCode), statusCode, http.StatusText(statusCode), html.EscapeString(message))
+		if stack != "" {
+			body = body + fmt.Sprintf("<pre>%v</pre>\n", html.EscapeString(stack))
+		}
+		return c.Response.HTML(body)
+	}
+	res := map[string]string{"message": message}
+	if stack != "" {
+		res["stack trace"] = stack
+	}
+	j, _ := json.Marshal(res)
+	return c.Response.Json(string(j))
+}
+
+// acceptsHTML checks whether the Accept header prefers html over json
+func acceptsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.Split(part, ";")[0])
+		switch {
+		case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+			return true
+		case mediaType == CONTENT_TYPE_JSON || strings.HasSuffix(mediaType, "+json"):
+			return false
+		}
+	}
+	return false
+}
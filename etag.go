@@ -0,0 +1,105 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ETagOptions struct {
+	// generates weak etags instead of strong ones
+	Weak bool
+}
+
+// ETag returns a middleware that sets the etag of the GET and HEAD responses from the hash of
+// the body, the etags set by the handler are kept.
+//
+// It sends 304 with an empty body when the request's If-None-Match matches the etag. When the
+// request has no If-None-Match, it sends 304 when the response's Last-Modified is not after the
+// request's If-Modified-Since.
+//
+// Register it after Compress, e.g. UseMiddleware(Compress()) then UseMiddleware(ETag()), so it
+// runs inside the compression middleware. This way the etags are computed from the uncompressed
+// bodies, and the 304 responses are sent without being compressed.
+func ETag(opts ...ETagOptions) Middleware {
+	var opt ETagOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return Around(func(c *Context, next func() *Response) *Response {
+		res := next()
+		r := c.Request.httpRequest
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return res
+		}
+		if res.IsStreamed() || res.redirectTo != "" || res.GetStatusCode() != http.StatusOK {
+			return res
+		}
+		if res.GetHeader("ETag") == "" {
+			sum := sha256.Sum256(res.body)
+			etag := "\"" + hex.EncodeToString(sum[:16]) + "\""
+			if opt.Weak {
+				etag = "W/" + etag
+			}
+			res.forceHeader("ETag", etag)
+		}
+		if notModified(r, res) {
+			res.statusCode = http.StatusNotModified
+			res.body = nil
+			res.delHeader("Content-Length")
+		}
+		return res
+	})
+}
+
+// notModified evaluates the conditional headers of the request against the response's validators,
+// If-None-Match takes precedence over If-Modified-Since
+func notModified(r *http.Request, rs *Response) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, rs.GetHeader("ETag"))
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	lm := rs.GetHeader("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagMatches compares the etags of the If-None-Match header with the etag using the weak comparison
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, "\"") && strings.HasSuffix(etag, "\"") && len(etag) > 1 {
+		return etag
+	}
+	return "\"" + etag + "\""
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestETag(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	modified := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	large := strings.Repeat("{\"name\": \"jack\"}", 100)
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response { return c.Response.Json(large) }))
+	r.Get("/articles/1", Handler(func(c *Context) *Response {
+		return c.Response.SetWeakETag("v3").SetLastModified(modified).Text("article")
+	}))
+	r.Get("/reports", Handler(func(c *Context) *Response {
+		return c.Response.SetLastModified(modified).SetETag("\"r1\"").Text("report")
+	}))
+	r.Post("/users", Handler(func(c *Context) *Response { return c.Response.Json("{}") }))
+	r.Get("/missing", Handler(func(c *Context) *Response {
+		return c.Response.SetStatusCode(http.StatusNotFound).Text("missing")
+	}))
+	UseMiddleware(Compress())
+	UseMiddleware(ETag())
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	serve := func(method string, path string, headers map[string]string) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		for key, val := range headers {
+			req.Header.Set(key, val)
+		}
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	rsp := serve("GET", "/users", nil)
+	etag := rsp.Header.Get("ETag")
+	if !strings.HasPrefix(etag, "\"") || len(etag) != 34 {
+		t.Fatalf("failed computing the etag from the body, found %v", etag)
+	}
+	rsp = serve("GET", "/users", map[string]string{"If-None-Match": "\"other\", " + etag})
+	b, _ := io.ReadAll(rsp.Body)
+	if rsp.StatusCode != http.StatusNotModified || len(b) != 0 || rsp.Header.Get("ETag") != etag {
+		t.Errorf("expected the matching If-None-Match to get 304, found %v %v", rsp.StatusCode, string(b))
+	}
+	rsp = serve("HEAD", "/users", map[string]string{"If-None-Match": etag})
+	if rsp.StatusCode != http.StatusNotModified || rsp.Header.Get("Content-Length") != "" {
+		t.Errorf("expected the HEAD request with a matching If-None-Match to get 304, found %v %v", rsp.StatusCode, rsp.Header)
+	}
+	rsp = serve("GET", "/users", map[string]string{"If-None-Match": "\"other\""})
+	if rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the request with another etag to get the response, found %v", rsp.StatusCode)
+	}
+
+	rsp = serve("GET", "/users", map[string]string{"Accept-Encoding": "gzip"})
+	if rsp.Header.Get("Content-Encoding") != "gzip" || rsp.Header.Get("ETag") != "W/"+etag {
+		t.Errorf("expected the etag of the compressed response to be weakened, found %v", rsp.Header)
+	}
+	rsp = serve("GET", "/users", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": "W/" + etag})
+	if rsp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected the weak etag of the compressed response to match, found %v", rsp.StatusCode)
+	}
+
+	rsp = serve("GET", "/articles/1", map[string]string{"If-None-Match": "\"v3\""})
+	if rsp.StatusCode != http.StatusNotModified || rsp.Header.Get("ETag") != "W/\"v3\"" || rsp.Header.Get("Last-Modified") != "Sat, 01 May 2021 10:00:00 GMT" {
+		t.Errorf("expected the handler's weak etag to match, found %v %v", rsp.StatusCode, rsp.Header)
+	}
+	rsp = serve("GET", "/articles/1", map[string]string{"If-Modified-Since": "Sat, 01 May 2021 10:00:00 GMT"})
+	if rsp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected the resource not modified since If-Modified-Since to get 304, found %v", rsp.StatusCode)
+	}
+	rsp = serve("GET", "/articles/1", map[string]string{"If-Modified-Since": "Sat, 01 May 2021 09:59:59 GMT"})
+	if rsp.StatusCode != http.StatusOK {
+		t.Errorf("expected the resource modified since If-Modified-Since to get 200, found %v", rsp.StatusCode)
+	}
+	rsp = serve("GET", "/reports", map[string]string{"If-None-Match": "\"r0\"", "If-Modified-Since": "Sat, 01 May 2021 10:00:00 GMT"})
+	if rsp.StatusCode != http.StatusOK || rsp.Header.Get("ETag") != "\"r1\"" {
+		t.Errorf("expected If-None-Match to take precedence over If-Modified-Since, found %v", rsp.StatusCode)
+	}
+
+	rsp = serve("POST", "/users", map[string]string{"If-None-Match": "*"})
+	if rsp.StatusCode != http.StatusOK || rsp.Header.Get("ETag") != "" {
+		t.Errorf("expected no etag for a POST request, found %v %v", rsp.StatusCode, rsp.Header)
+	}
+	rsp = serve("GET", "/missing", map[string]string{"If-None-Match": "*"})
+	if rsp.StatusCode != http.StatusNotFound || rsp.Header.Get("ETag") != "" {
+		t.Errorf("expected no etag for an error response, found %v %v", rsp.StatusCode, rsp.Header)
+	}
+}
+
+func TestETagWeak(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response { return c.Response.Json("[]") }), ETag(ETagOptions{Weak: true}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	etag := w.Header().Get("ETag")
+	if !strings.HasPrefix(etag, "W/\"") {
+		t.Fatalf("failed computing a weak etag, found %v", etag)
+	}
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("If-None-Match", strings.TrimPrefix(etag, "W/"))
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected the weak comparison of If-None-Match to match, found %v", w.Code)
+	}
+}
@@ -3,18 +3,22 @@ package core
 import (
 	"errors"
 	"fmt"
+	"sync"
 )
 
 type EventsManager struct {
+	// guards the jobs list and the fired events, shared with the managers of the requests
+	mu             *sync.RWMutex
 	eventsJobsList map[string][]EventJob
 	firedEvents    []*Event
+	requestContext *Context
 }
 
 var manager *EventsManager
-var rqc *Context
 
 func NewEventsManager() *EventsManager {
 	manager = &EventsManager{
+		mu:             &sync.RWMutex{},
 		eventsJobsList: map[string][]EventJob{},
 	}
 
@@ -25,10 +29,14 @@ func ResolveEventsManager() *EventsManager {
 	return manager
 }
 
-func (m *EventsManager) setContext(requestContext *Context) *EventsManager {
-	rqc = requestContext
-
-	return m
+// forRequest returns a manager that shares the registered jobs but keeps
+// its own fired events, so concurrent requests don't process each other's events
+func (m *EventsManager) forRequest(requestContext *Context) *EventsManager {
+	return &EventsManager{
+		mu:             m.mu,
+		eventsJobsList: m.eventsJobsList,
+		requestContext: requestContext,
+	}
 }
 
 func (m *EventsManager) Fire(e *Event) error {
@@ -38,6 +46,8 @@ func (m *EventsManager) Fire(e *Event) error {
 	if e.Name == "" {
 		return errors.New("event name is empty")
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	_, exists := m.eventsJobsList[e.Name]
 	if !exists {
 		return errors.New(fmt.Sprintf("event %v is not registered", e.Name))
@@ -54,6 +64,8 @@ func (m *EventsManager) Register(eName string, job EventJob) {
 	if eName == "" {
 		panic("event name is empty")
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	_, exists := m.eventsJobsList[eName]
 	if !exists {
 		m.eventsJobsList[eName] = []EventJob{job}
@@ -72,18 +84,25 @@ func (m *EventsManager) processFiredEvents() {
 	if disableEvents {
 		return
 	}
-	for _, event := range m.firedEvents {
+	m.mu.Lock()
+	fired := m.firedEvents
+	m.firedEvents = []*Event{}
+	m.mu.Unlock()
+	for _, event := range fired {
 		m.executeEventJobs(event)
 	}
-	m.firedEvents = []*Event{}
 }
 
 func (m *EventsManager) executeEventJobs(event *Event) {
+	m.mu.RLock()
+	var eventJobs []EventJob
 	for key, jobs := range m.eventsJobsList {
 		if key == event.Name {
-			for _, job := range jobs {
-				job(event, rqc)
-			}
+			eventJobs = append(eventJobs, jobs...)
 		}
 	}
+	m.mu.RUnlock()
+	for _, job := range eventJobs {
+		job(event, m.requestContext)
+	}
 }
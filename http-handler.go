@@ -0,0 +1,93 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var allMethods = []string{GET, POST, DELETE, PATCH, PUT, OPTIONS, HEAD}
+
+// WrapHTTPHandler converts a net/http handler to a Handler, the path params are
+// accessible in the wrapped handler through httprouter.ParamsFromContext
+func WrapHTTPHandler(h http.Handler) Handler {
+	return func(c *Context) *Response {
+		ps := c.Request.httpPathParams
+		c.Response.stream = func(w http.ResponseWriter, r *http.Request) {
+			if len(ps) != 0 {
+				r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, ps))
+			}
+			h.ServeHTTP(w, r)
+		}
+		return c.Response
+	}
+}
+
+// Handle registers a net/http handler for the given method and path, the handler runs after the global and the given middlewares
+func (r *Router) Handle(method string, path string, h http.Handler, middlewares ...Middleware) *Router {
+	r.addRoute(method, path, WrapHTTPHandler(h), middlewares)
+	r.setRawHandlerName(h)
+	return r
+}
+
+// HandleFunc registers a net/http handler function for the given method and path
+func (r *Router) HandleFunc(method string, path string, f http.HandlerFunc, middlewares ...Middleware) *Router {
+	return r.Handle(method, path, f, middlewares...)
+}
+
+// Mount serves the given net/http handler under the path prefix for all http methods,
+// the prefix is stripped from the request path before it's passed to the handler,
+// this can be used to mount a separately built router under a prefix
+func (r *Router) Mount(prefix string, h http.Handler, middlewares ...Middleware) *Router {
+	p := joinPaths(prefix, "*mountpath")
+	hdlr := mountHandler(h)
+	if joinPaths(r.prefix, p) == "/*mountpath" {
+		r.addFallback(allMethods, p, hdlr, middlewares)
+		r.setRawHandlerName(h)
+		return r
+	}
+	rt := r.root()
+	n := 0
+	for _, method := range allMethods {
+		r.addRoute(method, p, hdlr, middlewares)
+		n = n + rt.lastAdded
+	}
+	rt.lastAdded = n
+	r.setRawHandlerName(h)
+	return r
+}
+
+func mountHandler(h http.Handler) Handler {
+	return func(c *Context) *Response {
+		rest := c.Request.httpPathParams.ByName("mountpath")
+		c.Response.stream = func(w http.ResponseWriter, r *http.Request) {
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.URL = new(url.URL)
+			*r2.URL = *r.URL
+			r2.URL.Path = "/" + strings.TrimPrefix(rest, "/")
+			r2.URL.RawPath = ""
+			h.ServeHTTP(w, r2)
+		}
+		return c.Response
+	}
+}
+
+// setRawHandlerName sets the handler name of the last added routes to the name of the net/http handler
+func (r *Router) setRawHandlerName(h http.Handler) {
+	name := funcName(h)
+	if name == "" {
+		name = fmt.Sprintf("%T", h)
+	}
+	for _, route := range r.lastRoutes("") {
+		route.handlerName = name
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestMount(t *testing.T) {
+	app := createNewApp(t)
+	UseMiddleware(func(c *Context) {
+		c.Response.SetHeader("X-Global", "yes")
+		c.Next()
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello from %v %v", r.Method, r.URL.Path)
+	})
+	r := NewRouter()
+	r.Mount("/legacy", mux)
+	r.Get("/legacy-free", Handler(func(c *Context) *Response {
+		return c.Response.Text("free")
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	for _, method := range []string{"GET", "POST"} {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest(method, "/legacy/hello", nil))
+		rsp := w.Result()
+		b, _ := io.ReadAll(rsp.Body)
+		if string(b) != "hello from "+method+" /hello" {
+			t.Errorf("failed testing mount, found %v", string(b))
+		}
+		if rsp.Header.Get("X-Global") != "yes" {
+			t.Errorf("failed running global middlewares for mounted handler")
+		}
+	}
+}
+
+func TestHandleFunc(t *testing.T) {
+	app := createNewApp(t)
+	r := NewRouter()
+	r.HandleFunc(GET, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %v", httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	})
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/users/7", nil))
+	b, _ := io.ReadAll(w.Result().Body)
+	if string(b) != "user 7" {
+		t.Errorf("failed testing handle func, found %v", string(b))
+	}
+	if r.GetRoutesInfo()[0].Handler == "" {
+		t.Errorf("failed setting the name of the net/http handler")
+	}
+}
+
+func TestMountApp(t *testing.T) {
+	subApp := createNewApp(t)
+	sub := NewRouter()
+	sub.Get("/status", Handler(func(c *Context) *Response {
+		return c.Response.Text("ok")
+	}))
+	subHandler := subApp.RegisterRoutes(sub.GetRoutes(), httprouter.New())
+	app := createNewApp(t)
+	r := NewRouter()
+	r.Mount("/", subHandler)
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	b, _ := io.ReadAll(w.Result().Body)
+	if string(b) != "ok" {
+		t.Errorf("failed mounting router at the root, found %v", string(b))
+	}
+}
+
+func TestMountNameAndWhere(t *testing.T) {
+	app := createNewApp(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%v %v", r.Method, r.URL.Path)
+	})
+	r := NewRouter()
+	r.Mount("/legacy", mux).Name("legacy").Where("mountpath", "/reports(/.*)?")
+	routes := r.GetRoutes()
+	if len(routes) != len(allMethods) {
+		t.Fatalf("failed testing mount, found %v routes", len(routes))
+	}
+	for _, route := range routes {
+		if route.Name != "legacy" || route.Constraints["mountpath"] != "/reports(/.*)?" || route.handlerName == "" {
+			t.Errorf("expected the name and the constraint to be set on the %v route of the mount, found %+v", route.Method, route)
+		}
+	}
+	hr := app.RegisterRoutes(routes, httprouter.New())
+	for _, method := range []string{"GET", "POST", "DELETE"} {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest(method, "/legacy/reports/1", nil))
+		if b, _ := io.ReadAll(w.Result().Body); string(b) != method+" /reports/1" {
+			t.Errorf("failed serving the mounted handler for %v, found %v", method, string(b))
+		}
+		w = httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest(method, "/legacy/users", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected the constraint to apply to %v, found %v", method, w.Code)
+		}
+	}
+}
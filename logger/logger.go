@@ -18,6 +18,8 @@ type Logger struct {
 	warningLogger *log.Logger
 	errorLogger   *log.Logger
 	debugLogger   *log.Logger
+	// added to every message, set by WithPrefix
+	prefix string
 }
 
 var l *Logger
@@ -71,20 +73,36 @@ func ResolveLogger() *Logger {
 	return l
 }
 
+// WithPrefix returns a logger that writes to the same target with the
+// prefix added to every message, e.g. "info: 2021/01/01 00:00:00 [prefix] msg"
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	pl := *l
+	pl.prefix = "[" + prefix + "]"
+	return &pl
+}
+
+func (l *Logger) println(lg *log.Logger, msg interface{}) {
+	if l.prefix == "" {
+		lg.Println(msg)
+		return
+	}
+	lg.Println(l.prefix, msg)
+}
+
 func (l *Logger) Info(msg interface{}) {
-	l.infoLogger.Println(msg)
+	l.println(l.infoLogger, msg)
 }
 
 func (l *Logger) Debug(msg interface{}) {
-	l.debugLogger.Println(msg)
+	l.println(l.debugLogger, msg)
 }
 
 func (l *Logger) Warning(msg interface{}) {
-	l.warningLogger.Println(msg)
+	l.println(l.warningLogger, msg)
 }
 
 func (l *Logger) Error(msg interface{}) {
-	l.errorLogger.Println(msg)
+	l.println(l.errorLogger, msg)
 }
 
 func CloseLogsFile() {
@@ -131,3 +131,26 @@ func TestError(t *testing.T) {
 		CloseLogsFile()
 	})
 }
+
+func TestWithPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), uuid.NewString())
+	l := NewLogger(&LogFileDriver{
+		FilePath: path,
+	})
+	l.WithPrefix("req-1").Error("DFT2V56H")
+	l.Info("K8SD2LQ4")
+	lf, err := os.Open(path)
+	if err != nil {
+		t.Error("failed testing with prefix")
+	}
+	d, err := io.ReadAll(lf)
+	if err != nil {
+		t.Error("failed testing with prefix")
+	}
+	if !strings.Contains(string(d), "[req-1] DFT2V56H") || strings.Contains(string(d), "[req-1] K8SD2LQ4") {
+		t.Errorf("failed testing with prefix, found %v", string(d))
+	}
+	t.Cleanup(func() {
+		CloseLogsFile()
+	})
+}
@@ -4,4 +4,82 @@
 
 package core
 
+import "reflect"
+
 type Middleware func(c *Context)
+
+// AroundMiddleware wraps the rest of the chain, next runs the middlewares after it and the handler
+// and returns the final response, its status, body and headers can be read and changed before
+// it's returned, panics in the rest of the chain are converted to the error handler's response
+type AroundMiddleware func(c *Context, next func() *Response) *Response
+
+// Around converts an around middleware to a middleware, e.g. for timing the handler:
+//
+//	Around(func(c *Context, next func() *Response) *Response {
+//		start := time.Now()
+//		res := next()
+//		return res.SetHeader("X-Response-Time", time.Since(start).String())
+//	})
+//
+// the returned response replaces the context's response if it's a different one
+func Around(m AroundMiddleware) Middleware {
+	name := funcName(m)
+	return func(c *Context) {
+		if c.describing != nil {
+			*c.describing = name
+			return
+		}
+		called := false
+		next := func() *Response {
+			if called {
+				return c.Response
+			}
+			called = true
+			func() {
+				defer func() {
+					if e := recover(); e != nil {
+						c.handleError(e)
+					}
+				}()
+				c.Next()
+			}()
+			return c.Response
+		}
+		res := m(c, next)
+		if res != nil && res != c.Response {
+			w := c.Response.HttpResponseWriter
+			*c.Response = *res
+			c.Response.HttpResponseWriter = w
+		}
+	}
+}
+
+// Terminable creates a middleware with a terminate hook, the hook runs after the response is
+// sent to the client, e.g. for flushing analytics or persisting the session
+func Terminable(handle Middleware, terminate func(c *Context)) Middleware {
+	name := middlewareName(handle)
+	return func(c *Context) {
+		if c.describing != nil {
+			*c.describing = name
+			return
+		}
+		c.OnTerminate(terminate)
+		handle(c)
+	}
+}
+
+// middlewareName returns the name of the middleware's function, e.g. core.Compress.func1, the closures
+// of Around() and Terminable() have the same name for all the middlewares they create, so when they
+// are called with a describing context they report the name of the function they wrap instead
+func middlewareName(mw Middleware) string {
+	if mw == nil {
+		return ""
+	}
+	pc := reflect.ValueOf(mw).Pointer()
+	if pc == reflect.ValueOf(Around(nil)).Pointer() || pc == reflect.ValueOf(Terminable(nil, nil)).Pointer() {
+		var name string
+		mw(&Context{describing: &name})
+		return name
+	}
+	return funcName(mw)
+}
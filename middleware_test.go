@@ -0,0 +1,141 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestAround(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	var order []string
+	outer := Middleware(func(c *Context) {
+		order = append(order, "outer before")
+		c.Next()
+		order = append(order, "outer after")
+	})
+	upper := Around(func(c *Context, next func() *Response) *Response {
+		order = append(order, "around before")
+		res := next()
+		order = append(order, "around after")
+		res.SetHeader("X-Status", c.CastToString(res.GetStatusCode()))
+		return res.SetBody([]byte(strings.ToUpper(string(res.GetBody()))))
+	})
+	r := NewRouter()
+	r.Get("/hello", Handler(func(c *Context) *Response {
+		order = append(order, "handler")
+		return c.Response.SetStatusCode(http.StatusAccepted).Text("hello")
+	}), outer, upper)
+	r.Get("/panic", Handler(func(c *Context) *Response {
+		panic(NewHTTPError(http.StatusConflict, "conflict"))
+	}), upper)
+	r.Get("/replace", Handler(func(c *Context) *Response {
+		return c.Response.Text("original")
+	}), Around(func(c *Context, next func() *Response) *Response {
+		next()
+		res := &Response{}
+		return res.SetHeader("X-Replaced", "yes").Json("{\"replaced\": true}")
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/hello", nil))
+	rsp := w.Result()
+	b, _ := io.ReadAll(rsp.Body)
+	if rsp.StatusCode != http.StatusAccepted || string(b) != "HELLO" || rsp.Header.Get("X-Status") != "202" {
+		t.Errorf("failed modifying the response after the handler, found %v %v %v", rsp.StatusCode, string(b), rsp.Header)
+	}
+	if strings.Join(order, ", ") != "outer before, around before, handler, around after, outer after" {
+		t.Errorf("failed running the around middleware in order, found %v", order)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set("Accept", CONTENT_TYPE_JSON)
+	hr.ServeHTTP(w, req)
+	rsp = w.Result()
+	b, _ = io.ReadAll(rsp.Body)
+	if rsp.StatusCode != http.StatusConflict || rsp.Header.Get("X-Status") != "409" || !strings.Contains(string(b), "CONFLICT") {
+		t.Errorf("failed passing the error response to the around middleware, found %v %v %v", rsp.StatusCode, string(b), rsp.Header)
+	}
+
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/replace", nil))
+	rsp = w.Result()
+	b, _ = io.ReadAll(rsp.Body)
+	if string(b) != "{\"replaced\": true}" || rsp.Header.Get("X-Replaced") != "yes" || rsp.Header.Get(CONTENT_TYPE) != CONTENT_TYPE_JSON {
+		t.Errorf("failed replacing the response, found %v %v", string(b), rsp.Header)
+	}
+}
+
+func TestTerminable(t *testing.T) {
+	for _, http2 := range []bool{false, true} {
+		app := createNewApp(t)
+		loggr = logger.NewLogger(&logger.LogNullDriver{})
+		unblock := make(chan struct{})
+		done := make(chan string, 1)
+		var order []string
+		session := Terminable(func(c *Context) {
+			c.Set("session", "saved")
+			c.Next()
+		}, func(c *Context) {
+			<-unblock
+			order = append(order, "session "+c.CastToString(c.Get("session")))
+		})
+		r := NewRouter()
+		r.Get("/", Handler(func(c *Context) *Response {
+			c.OnTerminate(func(c *Context) {
+				panic("terminate failed")
+			})
+			c.OnTerminate(func(c *Context) {
+				order = append(order, "handler")
+				done <- strings.Join(order, ", ")
+			})
+			return c.Response.Text("hello")
+		}), session)
+		srv := httptest.NewUnstartedServer(app.RegisterRoutes(r.GetRoutes(), httprouter.New()))
+		client := &http.Client{Timeout: 2 * time.Second}
+		if http2 {
+			// the http/2 stream is closed only after the handler returns
+			srv.EnableHTTP2 = true
+			srv.StartTLS()
+			client = srv.Client()
+			client.Timeout = 2 * time.Second
+		} else {
+			srv.Start()
+		}
+
+		rsp, err := client.Get(srv.URL)
+		if err != nil {
+			close(unblock)
+			srv.Close()
+			t.Fatalf("http2 %v: failed getting the response before the terminate hooks finished: %v", http2, err)
+		}
+		b, err := io.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		close(unblock)
+		if err != nil || string(b) != "hello" || rsp.ProtoMajor != map[bool]int{false: 1, true: 2}[http2] {
+			t.Errorf("http2 %v: failed sending the response before the terminate hooks, found %v %v %v", http2, rsp.Proto, string(b), err)
+		}
+		select {
+		case res := <-done:
+			if res != "session saved, handler" {
+				t.Errorf("http2 %v: failed running the terminate hooks in order, found %v", http2, res)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("http2 %v: the terminate hooks didn't run", http2)
+		}
+		srv.Close()
+	}
+}
@@ -4,8 +4,29 @@
 
 package core
 
+import (
+	"fmt"
+	"sort"
+)
+
 type Middlewares struct {
 	middlewares []Middleware
+	// the names of the global middlewares, empty for the ones attached without a name
+	names []string
+	// the middlewares registered under names, e.g. "csrf", func values can't be compared,
+	// so the middlewares are told apart by these names, e.g. to skip one for a route
+	aliases map[string]Middleware
+	// named lists of middleware names the routes can use by name, e.g. "web" and "api"
+	groups map[string][]string
+	// the names of the middlewares in the order they run relative to each other
+	priority []string
+}
+
+// namedMiddleware is a middleware resolved for a route with the name it's registered under
+type namedMiddleware struct {
+	name   string
+	mw     Middleware
+	global bool
 }
 
 var m *Middlewares
@@ -21,10 +42,34 @@ func ResolveMiddlewares() *Middlewares {
 
 func (m *Middlewares) Attach(mw Middleware) *Middlewares {
 	m.middlewares = append(m.middlewares, mw)
+	m.names = append(m.names, "")
 
 	return m
 }
 
+// Alias registers the middleware under the given name, e.g. "csrf", the routes and the middleware
+// groups use it by its name, and the name is used to skip it for a route or to set its priority
+func (m *Middlewares) Alias(name string, mw Middleware) *Middlewares {
+	if m.aliases == nil {
+		m.aliases = map[string]Middleware{}
+	}
+	m.aliases[name] = mw
+	return m
+}
+
+// AttachByName attaches the middlewares registered under the given names as global middlewares
+func (m *Middlewares) AttachByName(names ...string) *Middlewares {
+	for _, name := range names {
+		mw, ok := m.aliases[name]
+		if !ok {
+			panic(fmt.Sprintf("middleware %v is not registered", name))
+		}
+		m.middlewares = append(m.middlewares, mw)
+		m.names = append(m.names, name)
+	}
+	return m
+}
+
 func (m *Middlewares) GetMiddlewares() []Middleware {
 	return m.middlewares
 }
@@ -37,3 +82,101 @@ func (m *Middlewares) getByIndex(i int) Middleware {
 	}
 	return nil
 }
+
+// AttachGroup registers a named group of the middlewares registered under the given names, routes
+// use the group through Router.WithMiddlewareGroups() or Router.Middleware()
+func (m *Middlewares) AttachGroup(name string, names ...string) *Middlewares {
+	if m.groups == nil {
+		m.groups = map[string][]string{}
+	}
+	m.groups[name] = append(m.groups[name], names...)
+	return m
+}
+
+// SetPriority sets the order the middlewares registered under the given names run in when a route
+// uses several of them, regardless of the order they are attached in, the others keep their positions
+func (m *Middlewares) SetPriority(names ...string) *Middlewares {
+	m.priority = names
+	return m
+}
+
+// resolve returns the middlewares of the route in the order they run
+func (m *Middlewares) resolve(route Route) []Middleware {
+	var res []Middleware
+	for _, nm := range m.resolveNamed(route) {
+		res = append(res, nm.mw)
+	}
+	return res
+}
+
+// resolveNamed returns the middlewares of the route with their names in the order they run, the global
+// middlewares come first followed by the route's middleware groups and named middlewares and its own middlewares
+func (m *Middlewares) resolveNamed(route Route) []namedMiddleware {
+	var res []namedMiddleware
+	for i, mw := range m.middlewares {
+		var name string
+		if i < len(m.names) {
+			name = m.names[i]
+		}
+		res = append(res, namedMiddleware{name: name, mw: mw, global: true})
+	}
+	for _, name := range route.middlewareNames {
+		names := []string{name}
+		if group, ok := m.groups[name]; ok {
+			names = group
+		} else if _, ok := m.aliases[name]; !ok {
+			panic(fmt.Sprintf("middleware or middleware group %v used by route %v is not registered", name, route.Path))
+		}
+		for _, n := range names {
+			mw, ok := m.aliases[n]
+			if !ok {
+				panic(fmt.Sprintf("middleware %v of group %v used by route %v is not registered", n, name, route.Path))
+			}
+			res = append(res, namedMiddleware{name: n, mw: mw})
+		}
+	}
+	for _, mw := range route.Middlewares {
+		res = append(res, namedMiddleware{mw: mw})
+	}
+	if len(route.withoutMiddlewares) != 0 {
+		kept := res[:0]
+		for _, nm := range res {
+			if nm.name == "" || indexOfName(route.withoutMiddlewares, nm.name) == -1 {
+				kept = append(kept, nm)
+			}
+		}
+		res = kept
+	}
+	return m.sortByPriority(res)
+}
+
+// sortByPriority reorders the middlewares listed in the priority among the positions they occupy
+func (m *Middlewares) sortByPriority(mws []namedMiddleware) []namedMiddleware {
+	if len(m.priority) == 0 {
+		return mws
+	}
+	var positions []int
+	var prioritized []namedMiddleware
+	for i, nm := range mws {
+		if nm.name != "" && indexOfName(m.priority, nm.name) != -1 {
+			positions = append(positions, i)
+			prioritized = append(prioritized, nm)
+		}
+	}
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		return indexOfName(m.priority, prioritized[i].name) < indexOfName(m.priority, prioritized[j].name)
+	})
+	for i, pos := range positions {
+		mws[pos] = prioritized[i]
+	}
+	return mws
+}
+
+func indexOfName(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
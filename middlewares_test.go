@@ -6,8 +6,12 @@ package core
 
 import (
 	"fmt"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
 )
 
 func TestNewMiddlewares(t *testing.T) {
@@ -62,3 +66,116 @@ func TestMiddlewareGetByIndex(t *testing.T) {
 		t.Errorf("failed testing get by index")
 	}
 }
+
+func TestMiddlewareGroupsAndPriority(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	global := Middleware(func(c *Context) { c.Response.SetHeader("X-Trace", "global"); c.Next() })
+	session := Middleware(func(c *Context) { c.Response.SetHeader("X-Trace", "session"); c.Next() })
+	csrf := Middleware(func(c *Context) { c.Response.SetHeader("X-Trace", "csrf"); c.Next() })
+	auth := Middleware(func(c *Context) { c.Response.SetHeader("X-Trace", "auth"); c.Next() })
+	throttle := Middleware(func(c *Context) { c.Response.SetHeader("X-Trace", "throttle"); c.Next() })
+	app.RegisterMiddleware("global", global)
+	app.RegisterMiddleware("session", session)
+	app.RegisterMiddleware("csrf", csrf)
+	app.RegisterMiddleware("auth", auth)
+	app.RegisterMiddleware("throttle", throttle)
+	app.UseNamedMiddlewares("global")
+	app.RegisterMiddlewareGroup("web", "session", "csrf")
+	app.RegisterMiddlewareGroup("api", "throttle")
+	app.SetMiddlewarePriority("auth", "session")
+	h := Handler(func(c *Context) *Response { return c.Response.Text("ok") })
+	r := NewRouter()
+	web := r.WithMiddlewareGroups("web")
+	web.Get("/dashboard", h).Middleware("auth")
+	web.Post("/webhook", h).WithoutMiddleware("csrf", "global")
+	r.Get("/api/users", h).Middleware("api")
+	r.Get("/plain", h)
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	cases := []struct {
+		method   string
+		path     string
+		expected []string
+	}{
+		{"GET", "/dashboard", []string{"global", "auth", "csrf", "session"}},
+		{"POST", "/webhook", []string{"session"}},
+		{"GET", "/api/users", []string{"global", "throttle"}},
+		{"GET", "/plain", []string{"global"}},
+	}
+	for _, cs := range cases {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest(cs.method, cs.path, nil))
+		trace := w.Result().Header.Values("X-Trace")
+		if !reflect.DeepEqual(trace, cs.expected) {
+			t.Errorf("failed resolving the middlewares of %v %v, found %v", cs.method, cs.path, trace)
+		}
+	}
+	info := r.GetRoutesInfo()
+	if !reflect.DeepEqual(info[0].Middlewares, []string{"global", "auth", "csrf", "session"}) || len(info[1].Middlewares) != 1 {
+		t.Errorf("failed listing the resolved middlewares in the routes info, found %v", info[0].Middlewares)
+	}
+
+	r = NewRouter()
+	r.Get("/missing", h).Middleware("missing")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a route with an unknown middleware group to panic")
+		}
+	}()
+	app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+}
+
+type testTracer struct {
+	name string
+}
+
+func (tr *testTracer) Trace(c *Context) {
+	c.Response.SetHeader("X-Trace", tr.name)
+	c.Next()
+}
+
+func TestNamedMiddlewares(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	trace := func(name string) Middleware {
+		return Around(func(c *Context, next func() *Response) *Response {
+			return next().SetHeader("X-Trace", name)
+		})
+	}
+	// the middlewares of the same constructor and the method values are told apart by their names
+	app.RegisterMiddleware("first", trace("first"))
+	app.RegisterMiddleware("second", trace("second"))
+	tracer := &testTracer{name: "method"}
+	app.RegisterMiddleware("method", tracer.Trace)
+	app.UseNamedMiddlewares("first", "second")
+	app.SetMiddlewarePriority("second", "first")
+	h := Handler(func(c *Context) *Response { return c.Response.Text("ok") })
+	r := NewRouter()
+	r.Get("/both", h)
+	r.Get("/without-first", h).WithoutMiddleware("first")
+	r.Get("/method", h, tracer.Trace).Middleware("method").WithoutMiddleware("method")
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	cases := map[string][]string{
+		"/both":          {"first", "second"},
+		"/without-first": {"second"},
+		"/method":        {"method", "first", "second"},
+	}
+	for path, expected := range cases {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if found := w.Result().Header.Values("X-Trace"); !reflect.DeepEqual(found, expected) {
+			t.Errorf("failed resolving the named middlewares for %v, found %v", path, found)
+		}
+	}
+	info := r.GetRoutesInfo()
+	if !reflect.DeepEqual(info[0].Middlewares, []string{"second", "first"}) || !reflect.DeepEqual(info[1].Middlewares, []string{"second"}) {
+		t.Errorf("failed listing the named middlewares in the routes info, found %v %v", info[0].Middlewares, info[1].Middlewares)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected using an unregistered middleware globally to panic")
+		}
+	}()
+	app.UseNamedMiddlewares("missing")
+}
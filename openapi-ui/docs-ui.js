@@ -0,0 +1,288 @@
+// renders the OpenAPI document of the app, the page is served without external assets
+// and inline scripts, so it works offline and under a strict content security policy
+(function () {
+	"use strict";
+
+	var METHODS = ["get", "put", "post", "delete", "options", "head", "patch", "trace"];
+	var root = document.getElementById("docs");
+	var spec = null;
+
+	function el(tag, className, text) {
+		var e = document.createElement(tag);
+		if (className) {
+			e.className = className;
+		}
+		if (text !== undefined && text !== null) {
+			e.textContent = String(text);
+		}
+		return e;
+	}
+
+	function resolve(schema) {
+		var seen = 0;
+		while (schema && schema.$ref && seen < 32) {
+			var name = schema.$ref.replace("#/components/schemas/", "");
+			schema = ((spec.components || {}).schemas || {})[name];
+			seen++;
+		}
+		return schema || {};
+	}
+
+	// sample returns a value shaped like the schema, the nested schemas are expanded up to a depth
+	function sample(schema, depth) {
+		schema = resolve(schema);
+		if (depth > 6) {
+			return "...";
+		}
+		if (schema.example !== undefined) {
+			return schema.example;
+		}
+		if (schema["enum"]) {
+			return schema["enum"][0];
+		}
+		switch (schema.type) {
+		case "object":
+			var obj = {};
+			Object.keys(schema.properties || {}).forEach(function (key) {
+				obj[key] = sample(schema.properties[key], depth + 1);
+			});
+			if (schema.additionalProperties) {
+				obj["<key>"] = sample(schema.additionalProperties, depth + 1);
+			}
+			return obj;
+		case "array":
+			return [sample(schema.items || {}, depth + 1)];
+		case "integer":
+		case "number":
+			return 0;
+		case "boolean":
+			return false;
+		case "string":
+			return schema.format ? "<" + schema.format + ">" : "string";
+		default:
+			return schema.properties ? sample({type: "object", properties: schema.properties}, depth) : null;
+		}
+	}
+
+	function schemaBlock(schema) {
+		return el("pre", "", JSON.stringify(sample(schema, 0), null, 2));
+	}
+
+	function typeOf(schema) {
+		schema = resolve(schema);
+		var t = schema.type || "";
+		if (t === "array") {
+			t = typeOf(schema.items || {}) + "[]";
+		}
+		if (schema.format) {
+			t += " (" + schema.format + ")";
+		}
+		return t;
+	}
+
+	function jsonContent(content) {
+		if (!content) {
+			return null;
+		}
+		return content["application/json"] || content[Object.keys(content)[0]];
+	}
+
+	function renderParams(params) {
+		var table = el("table");
+		var head = el("tr");
+		["Name", "In", "Type", "Description"].forEach(function (h) {
+			head.appendChild(el("th", "", h));
+		});
+		table.appendChild(head);
+		params.forEach(function (p) {
+			var row = el("tr");
+			var name = el("td", "", p.name);
+			if (p.required) {
+				name.appendChild(el("span", "required", " *"));
+			}
+			row.appendChild(name);
+			row.appendChild(el("td", "", p["in"]));
+			row.appendChild(el("td", "", typeOf(p.schema || {})));
+			row.appendChild(el("td", "", p.description || resolve(p.schema || {}).description || ""));
+			table.appendChild(row);
+		});
+		return table;
+	}
+
+	function renderResponses(responses) {
+		var table = el("table");
+		var head = el("tr");
+		["Status", "Description", "Body"].forEach(function (h) {
+			head.appendChild(el("th", "", h));
+		});
+		table.appendChild(head);
+		Object.keys(responses).sort().forEach(function (code) {
+			var res = responses[code];
+			var row = el("tr");
+			row.appendChild(el("td", "", code));
+			row.appendChild(el("td", "", res.description || ""));
+			var body = el("td");
+			var content = jsonContent(res.content);
+			if (content && content.schema) {
+				body.appendChild(schemaBlock(content.schema));
+			}
+			row.appendChild(body);
+			table.appendChild(row);
+		});
+		return table;
+	}
+
+	// renderTry renders a form that sends the request from the page and shows the response
+	function renderTry(method, path, params, op) {
+		var form = el("form", "try");
+		var inputs = {};
+		params.forEach(function (p) {
+			if (p["in"] !== "path" && p["in"] !== "query") {
+				return;
+			}
+			var label = el("label", "", p.name + " (" + p["in"] + ")");
+			var input = el("input");
+			input.name = p.name;
+			input.required = !!p.required;
+			label.appendChild(input);
+			form.appendChild(label);
+			inputs[p.name] = {param: p, input: input};
+		});
+		var body = null;
+		var content = op.requestBody ? jsonContent(op.requestBody.content) : null;
+		if (content) {
+			var label = el("label", "", "body");
+			body = el("textarea");
+			body.value = JSON.stringify(sample(content.schema || {}, 0), null, 2);
+			label.appendChild(body);
+			form.appendChild(label);
+		}
+		form.appendChild(el("button", "", "Send"));
+		var status = el("div", "status");
+		var output = el("pre");
+		form.addEventListener("submit", function (e) {
+			e.preventDefault();
+			var url = path;
+			var query = [];
+			Object.keys(inputs).forEach(function (name) {
+				var v = inputs[name].input.value;
+				if (inputs[name].param["in"] === "path") {
+					url = url.replace("{" + name + "}", encodeURIComponent(v));
+				} else if (v !== "") {
+					query.push(encodeURIComponent(name) + "=" + encodeURIComponent(v));
+				}
+			});
+			if (query.length) {
+				url += "?" + query.join("&");
+			}
+			var init = {method: method.toUpperCase(), headers: {Accept: "application/json"}};
+			if (body) {
+				init.headers["Content-Type"] = "application/json";
+				init.body = body.value;
+			}
+			status.textContent = "Sending...";
+			output.textContent = "";
+			fetch(url, init).then(function (res) {
+				status.textContent = res.status + " " + res.statusText;
+				return res.text();
+			}).then(function (text) {
+				try {
+					output.textContent = JSON.stringify(JSON.parse(text), null, 2);
+				} catch (err) {
+					output.textContent = text;
+				}
+			}).catch(function (err) {
+				status.textContent = "Error: " + err.message;
+			});
+		});
+		form.appendChild(status);
+		form.appendChild(output);
+		return form;
+	}
+
+	function renderOperation(method, path, op, shared) {
+		var details = el("details", "operation" + (op.deprecated ? " deprecated" : ""));
+		var summary = el("summary");
+		summary.appendChild(el("span", "method " + method, method));
+		summary.appendChild(el("span", "path", path));
+		summary.appendChild(el("span", "summary", op.summary || ""));
+		details.appendChild(summary);
+		var body = el("div", "body");
+		if (op.deprecated) {
+			body.appendChild(el("p", "required", "Deprecated"));
+		}
+		if (op.description) {
+			body.appendChild(el("p", "description", op.description));
+		}
+		var params = (shared || []).concat(op.parameters || []);
+		if (params.length) {
+			body.appendChild(el("h4", "", "Parameters"));
+			body.appendChild(renderParams(params));
+		}
+		var content = op.requestBody ? jsonContent(op.requestBody.content) : null;
+		if (content && content.schema) {
+			body.appendChild(el("h4", "", "Request body"));
+			body.appendChild(schemaBlock(content.schema));
+		}
+		if (op.responses) {
+			body.appendChild(el("h4", "", "Responses"));
+			body.appendChild(renderResponses(op.responses));
+		}
+		body.appendChild(el("h4", "", "Try it"));
+		body.appendChild(renderTry(method, path, params, op));
+		details.appendChild(body);
+		return details;
+	}
+
+	function render() {
+		root.textContent = "";
+		var info = spec.info || {};
+		var title = el("h1", "", info.title || "API");
+		if (info.version) {
+			title.appendChild(el("span", "version", info.version));
+		}
+		root.appendChild(title);
+		if (info.description) {
+			root.appendChild(el("p", "description", info.description));
+		}
+		(spec.servers || []).forEach(function (s) {
+			root.appendChild(el("div", "servers", s.url));
+		});
+		var tags = {};
+		var order = [];
+		Object.keys(spec.paths || {}).sort().forEach(function (path) {
+			var item = spec.paths[path];
+			METHODS.forEach(function (method) {
+				var op = item[method];
+				if (!op) {
+					return;
+				}
+				var tag = (op.tags && op.tags[0]) || "default";
+				if (!tags[tag]) {
+					tags[tag] = [];
+					order.push(tag);
+				}
+				tags[tag].push(renderOperation(method, path, op, item.parameters));
+			});
+		});
+		order.forEach(function (tag) {
+			root.appendChild(el("h2", "", tag));
+			tags[tag].forEach(function (op) {
+				root.appendChild(op);
+			});
+		});
+	}
+
+	fetch(root.getAttribute("data-url"), {headers: {Accept: "application/json"}}).then(function (res) {
+		if (!res.ok) {
+			throw new Error(res.status + " " + res.statusText);
+		}
+		return res.json();
+	}).then(function (doc) {
+		spec = doc;
+		render();
+	}).catch(function (err) {
+		root.textContent = "";
+		root.appendChild(el("p", "error", "Failed loading the API documentation: " + err.message));
+	});
+})();
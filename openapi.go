@@ -0,0 +1,503 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteDoc holds the documentation of a route used to generate the OpenAPI document
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// the type of the request body, e.g. CreateUserRequest{}
+	Request interface{}
+	// validation rules of the request params in the Validator's format, e.g. {"email": "required|email"},
+	// they describe the query params of GET, HEAD and DELETE routes and the body of the others
+	Rules map[string]interface{}
+	// the types of the responses by status code, e.g. {200: User{}}
+	Responses  map[int]interface{}
+	Deprecated bool
+}
+
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+	Servers     []string
+}
+
+type OpenAPIOptions struct {
+	// the path the document is served at, default is /openapi.json
+	Path string
+	// the path the docs page is served at, the page is disabled if it's empty, it's a small viewer
+	// built into the package, not swagger ui, it lists the operations of the document and can send
+	// requests to them, its assets are embedded and served under the path, so it works offline and
+	// with the default csp, serve swagger ui yourself from the document's path for its full features
+	DocsUIPath string
+}
+
+// Doc sets the documentation of the last added route
+func (r *Router) Doc(doc RouteDoc) *Router {
+	for _, route := range r.lastRoutes("can not set the doc, no routes are added") {
+		d := doc
+		route.Doc = &d
+	}
+	return r
+}
+
+// ServeOpenAPI serves the OpenAPI document of the routes, the document is generated on the first request
+func (r *Router) ServeOpenAPI(info OpenAPIInfo, opts ...OpenAPIOptions) *Router {
+	var opt OpenAPIOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Path == "" {
+		opt.Path = "/openapi.json"
+	}
+	var once sync.Once
+	var doc []byte
+	var docErr error
+	rt := r.root()
+	r.Get(opt.Path, func(c *Context) *Response {
+		once.Do(func() {
+			var routes []Route
+			for _, route := range rt.GetRoutes() {
+				if route.openAPI {
+					continue
+				}
+				routes = append(routes, route)
+			}
+			doc, docErr = GenerateOpenAPI(routes, info)
+		})
+		if docErr != nil {
+			panic(fmt.Sprintf("error generating the openapi document: %v", docErr))
+		}
+		return c.Response.Json(string(doc))
+	})
+	for _, route := range r.lastRoutes("") {
+		route.openAPI = true
+	}
+	if opt.DocsUIPath != "" {
+		assetsURL := joinPaths(joinPaths(r.prefix, opt.DocsUIPath), "assets")
+		page := fmt.Sprintf(openAPIUIPage,
+			html.EscapeString(info.Title),
+			html.EscapeString(assetsURL+"/docs-ui.css"),
+			html.EscapeString(joinPaths(r.prefix, opt.Path)),
+			html.EscapeString(assetsURL+"/docs-ui.js"),
+		)
+		r.Get(opt.DocsUIPath, func(c *Context) *Response {
+			return c.Response.HTML(page)
+		})
+		for _, route := range r.lastRoutes("") {
+			route.openAPI = true
+		}
+		r.Get(joinPaths(opt.DocsUIPath, "assets/:file"), serveOpenAPIUIAsset)
+		for _, route := range r.lastRoutes("") {
+			route.openAPI = true
+		}
+	}
+	return r
+}
+
+// GenerateOpenAPI generates an OpenAPI 3 document in json from the given routes
+func GenerateOpenAPI(routes []Route, info OpenAPIInfo) ([]byte, error) {
+	g := &openAPIGenerator{
+		schemas: map[string]interface{}{},
+	}
+	paths := map[string]map[string]interface{}{}
+	for _, route := range routes {
+		if route.fallback || route.version != "" || route.Method == HEAD || route.Method == OPTIONS {
+			continue
+		}
+		p := openAPIPath(route.Path)
+		if _, ok := paths[p]; !ok {
+			paths[p] = map[string]interface{}{}
+		}
+		paths[p][route.Method] = g.operation(route)
+	}
+	apiInfo := map[string]interface{}{
+		"title":   info.Title,
+		"version": info.Version,
+	}
+	if info.Description != "" {
+		apiInfo["description"] = info.Description
+	}
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    apiInfo,
+		"paths":   paths,
+	}
+	if len(info.Servers) > 0 {
+		var servers []map[string]string
+		for _, s := range info.Servers {
+			servers = append(servers, map[string]string{"url": s})
+		}
+		doc["servers"] = servers
+	}
+	if len(g.schemas) > 0 {
+		doc["components"] = map[string]interface{}{"schemas": g.schemas}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type openAPIGenerator struct {
+	schemas map[string]interface{}
+}
+
+func (g *openAPIGenerator) operation(route Route) map[string]interface{} {
+	op := map[string]interface{}{}
+	doc := route.Doc
+	if doc == nil {
+		doc = &RouteDoc{}
+	}
+	if route.Name != "" {
+		op["operationId"] = route.Name
+	}
+	if doc.Summary != "" {
+		op["summary"] = doc.Summary
+	}
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+	if len(doc.Tags) > 0 {
+		op["tags"] = doc.Tags
+	}
+	if doc.Deprecated {
+		op["deprecated"] = true
+	}
+	var params []map[string]interface{}
+	for _, segment := range strings.Split(route.Path, "/") {
+		if segment == "" || (segment[0:1] != ":" && segment[0:1] != "*") {
+			continue
+		}
+		name := segment[1:]
+		schema := map[string]interface{}{"type": "string"}
+		if pattern, ok := route.Constraints[name]; ok {
+			schema["pattern"] = "^(?:" + pattern + ")$"
+		}
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+	}
+	bodyMethod := route.Method == POST || route.Method == PUT || route.Method == PATCH
+	if !bodyMethod {
+		for _, name := range sortedKeys(doc.Rules) {
+			schema, required := rulesToSchema(doc.Rules[name])
+			params = append(params, map[string]interface{}{
+				"name":     name,
+				"in":       "query",
+				"required": required,
+				"schema":   schema,
+			})
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if bodyMethod && (doc.Request != nil || len(doc.Rules) > 0) {
+		schema := g.bodySchema(doc)
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				CONTENT_TYPE_JSON:                   map[string]interface{}{"schema": schema},
+				"application/x-www-form-urlencoded": map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+	responses := map[string]interface{}{}
+	for code, typ := range doc.Responses {
+		res := map[string]interface{}{"description": http.StatusText(code)}
+		if typ != nil {
+			res["content"] = map[string]interface{}{
+				CONTENT_TYPE_JSON: map[string]interface{}{"schema": g.schema(reflect.TypeOf(typ))},
+			}
+		}
+		responses[strconv.Itoa(code)] = res
+	}
+	if len(responses) == 0 {
+		responses["200"] = map[string]interface{}{"description": http.StatusText(http.StatusOK)}
+	}
+	op["responses"] = responses
+	return op
+}
+
+// bodySchema merges the schema of the request type with the schema of the validation rules
+func (g *openAPIGenerator) bodySchema(doc *RouteDoc) map[string]interface{} {
+	if len(doc.Rules) == 0 {
+		return g.schema(reflect.TypeOf(doc.Request))
+	}
+	properties := map[string]interface{}{}
+	var required []string
+	if doc.Request != nil {
+		t := reflect.TypeOf(doc.Request)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			props, req := g.structProperties(t)
+			for k, v := range props {
+				properties[k] = v
+			}
+			required = append(required, req...)
+		}
+	}
+	for _, name := range sortedKeys(doc.Rules) {
+		schema, isRequired := rulesToSchema(doc.Rules[name])
+		if existing, ok := properties[name].(map[string]interface{}); ok {
+			for k, v := range schema {
+				if k == "type" {
+					continue
+				}
+				existing[k] = v
+			}
+		} else {
+			properties[name] = schema
+		}
+		if isRequired && !containsString(required, name) {
+			required = append(required, name)
+		}
+	}
+	res := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		res["required"] = required
+	}
+	return res
+}
+
+// schema converts a go type to a json schema, named structs are added to the components
+func (g *openAPIGenerator) schema(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := strings.ReplaceAll(t.String(), ".", "_")
+		if _, ok := g.schemas[name]; !ok {
+			// registered before it's generated so recursive types refer to themselves
+			g.schemas[name] = map[string]interface{}{}
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (g *openAPIGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties, required := g.structProperties(t)
+	res := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		res["required"] = required
+	}
+	return res
+}
+
+// structProperties returns the schemas of the struct's fields by their json names,
+// fields without omitempty are listed as required
+func (g *openAPIGenerator) structProperties(t reflect.Type) (map[string]interface{}, []string) {
+	properties := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		omitempty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			omitempty = containsString(parts[1:], "omitempty")
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			props, req := g.structProperties(f.Type)
+			for k, v := range props {
+				properties[k] = v
+			}
+			required = append(required, req...)
+			continue
+		}
+		properties[name] = g.schema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+	return properties, required
+}
+
+// rulesToSchema converts validation rules like "required|email|length: 3, 20" to a json schema
+func rulesToSchema(rawRules interface{}) (map[string]interface{}, bool) {
+	schema := map[string]interface{}{"type": "string"}
+	required := false
+	rulesStr, ok := rawRules.(string)
+	if !ok {
+		return schema, false
+	}
+	var notes []string
+	for _, rule := range strings.Split(rulesStr, "|") {
+		rule = strings.TrimSpace(rule)
+		name, arg, _ := strings.Cut(rule, ":")
+		name = strings.TrimSpace(name)
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema["format"] = "email"
+		case "url":
+			schema["format"] = "uri"
+		case "uuid":
+			schema["format"] = "uuid"
+		case "ipv4", "ipv6":
+			schema["format"] = name
+		case "host", "domain", "dnsName":
+			schema["format"] = "hostname"
+		case "base64":
+			schema["format"] = "byte"
+		case "int":
+			schema["type"] = "integer"
+		case "float":
+			schema["type"] = "number"
+		case "alpha":
+			schema["pattern"] = "^[a-zA-Z]+$"
+		case "digit":
+			schema["pattern"] = "^[0-9]+$"
+		case "alphaNumeric":
+			schema["pattern"] = "^[a-zA-Z0-9]+$"
+		case "length":
+			lengthRange := strings.Split(arg, ",")
+			if min, err := strconv.Atoi(strings.TrimSpace(lengthRange[0])); err == nil {
+				schema["minLength"] = min
+			}
+			if len(lengthRange) > 1 {
+				if max, err := strconv.Atoi(strings.TrimSpace(lengthRange[1])); err == nil && max > 0 {
+					schema["maxLength"] = max
+				}
+			}
+		case "min", "max":
+			if n, err := strconv.ParseInt(arg, 10, 64); err == nil {
+				if schema["type"] == "string" {
+					schema["type"] = "integer"
+				}
+				if name == "min" {
+					schema["minimum"] = n
+				} else {
+					schema["maximum"] = n
+				}
+			}
+		case "in":
+			var enum []string
+			for _, elm := range strings.Split(arg, ",") {
+				enum = append(enum, strings.TrimSpace(elm))
+			}
+			schema["enum"] = enum
+		case "dateLayout":
+			notes = append(notes, fmt.Sprintf("date in the layout %q", arg))
+		case "":
+		default:
+			notes = append(notes, name)
+		}
+	}
+	if len(notes) > 0 {
+		schema["description"] = strings.Join(notes, ", ")
+	}
+	return schema, required
+}
+
+// openAPIPath converts the httprouter params in the path to OpenAPI params, e.g. /users/:id -> /users/{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && (segment[0:1] == ":" || segment[0:1] == "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// the docs page and its assets, the page is formatted with the title and the urls of the assets and the document
+var (
+	//go:embed openapi-ui/index.html
+	openAPIUIPage string
+	//go:embed openapi-ui/docs-ui.css openapi-ui/docs-ui.js
+	openAPIUIAssets embed.FS
+)
+
+var openAPIUIContentTypes = map[string]string{
+	".css": "text/css; charset=utf-8",
+	".js":  "text/javascript; charset=utf-8",
+}
+
+func serveOpenAPIUIAsset(c *Context) *Response {
+	name := c.Request.httpPathParams.ByName("file")
+	b, err := openAPIUIAssets.ReadFile("openapi-ui/" + name)
+	if err != nil {
+		return c.handleError(NewHTTPError(http.StatusNotFound, "Not Found"))
+	}
+	return c.Response.SetContentType(openAPIUIContentTypes[path.Ext(name)]).SetHeader("Cache-Control", "public, max-age=3600").SetBody(b)
+}
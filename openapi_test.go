@@ -0,0 +1,149 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type testOpenAPIUser struct {
+	ID        int64             `json:"id"`
+	Email     string            `json:"email"`
+	Nickname  string            `json:"nickname,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Friends   []testOpenAPIUser `json:"friends,omitempty"`
+	password  string
+}
+
+type testOpenAPICreateUser struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+func TestRulesToSchema(t *testing.T) {
+	schema, required := rulesToSchema("required|email|length: 3, 20")
+	if !required || schema["type"] != "string" || schema["format"] != "email" || schema["minLength"] != 3 || schema["maxLength"] != 20 {
+		t.Errorf("failed converting rules to schema: %v", schema)
+	}
+	schema, required = rulesToSchema("min: 1 | max: 10")
+	if required || schema["type"] != "integer" || schema["minimum"] != int64(1) || schema["maximum"] != int64(10) {
+		t.Errorf("failed converting rules to schema: %v", schema)
+	}
+	schema, _ = rulesToSchema("in: draft, published")
+	if !reflect.DeepEqual(schema["enum"], []string{"draft", "published"}) {
+		t.Errorf("failed converting rules to schema: %v", schema)
+	}
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	r := NewRouter()
+	h := Handler(func(c *Context) *Response { return nil })
+	r.Get("/users/:id", h).WhereNumber("id").Name("users.show").Doc(RouteDoc{
+		Summary:   "show a user",
+		Tags:      []string{"users"},
+		Responses: map[int]interface{}{200: testOpenAPIUser{}, 404: nil},
+	})
+	r.Get("/users", h).Doc(RouteDoc{
+		Rules: map[string]interface{}{"page": "int|min: 1"},
+	})
+	r.Post("/users", h).Doc(RouteDoc{
+		Request: testOpenAPICreateUser{},
+		Rules:   map[string]interface{}{"email": "required|email", "name": "length: 2, 50", "age": "int"},
+	})
+	j, err := GenerateOpenAPI(r.GetRoutes(), OpenAPIInfo{Title: "test api", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("failed generating openapi document: %v", err)
+	}
+	var doc map[string]interface{}
+	json.Unmarshal(j, &doc)
+	paths := doc["paths"].(map[string]interface{})
+	show := paths["/users/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	if show["operationId"] != "users.show" || show["summary"] != "show a user" {
+		t.Errorf("failed generating operation")
+	}
+	param := show["parameters"].([]interface{})[0].(map[string]interface{})
+	if param["in"] != "path" || param["schema"].(map[string]interface{})["pattern"] != "^(?:[0-9]+)$" {
+		t.Errorf("failed generating path param")
+	}
+	ok := show["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	ref := ok["content"].(map[string]interface{})[CONTENT_TYPE_JSON].(map[string]interface{})["schema"].(map[string]interface{})["$ref"]
+	if ref != "#/components/schemas/core_testOpenAPIUser" {
+		t.Errorf("failed generating response schema ref, found %v", ref)
+	}
+	user := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["core_testOpenAPIUser"].(map[string]interface{})
+	props := user["properties"].(map[string]interface{})
+	if len(props) != 5 || props["created_at"].(map[string]interface{})["format"] != "date-time" {
+		t.Errorf("failed generating struct schema: %v", props)
+	}
+	if !reflect.DeepEqual(user["required"], []interface{}{"id", "email", "created_at"}) {
+		t.Errorf("failed generating required fields: %v", user["required"])
+	}
+	list := paths["/users"].(map[string]interface{})["get"].(map[string]interface{})
+	query := list["parameters"].([]interface{})[0].(map[string]interface{})
+	if query["in"] != "query" || query["name"] != "page" || query["schema"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("failed generating query param from rules")
+	}
+	store := paths["/users"].(map[string]interface{})["post"].(map[string]interface{})
+	body := store["requestBody"].(map[string]interface{})["content"].(map[string]interface{})[CONTENT_TYPE_JSON].(map[string]interface{})["schema"].(map[string]interface{})
+	bodyProps := body["properties"].(map[string]interface{})
+	if bodyProps["email"].(map[string]interface{})["format"] != "email" || bodyProps["name"].(map[string]interface{})["maxLength"] != float64(50) || bodyProps["age"] == nil {
+		t.Errorf("failed generating request body schema: %v", bodyProps)
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	app := createNewApp(t)
+	r := NewRouter()
+	r.Get("/ping", Handler(func(c *Context) *Response { return c.Response.Text("pong") }))
+	r.Group("/docs").ServeOpenAPI(OpenAPIInfo{Title: "test api", Version: "1.0.0"}, OpenAPIOptions{DocsUIPath: "/ui"})
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/docs/openapi.json", nil))
+	var doc map[string]interface{}
+	err := json.NewDecoder(w.Result().Body).Decode(&doc)
+	if err != nil {
+		t.Fatalf("failed serving openapi document: %v", err)
+	}
+	paths := doc["paths"].(map[string]interface{})
+	if len(paths) != 1 || paths["/ping"] == nil {
+		t.Errorf("failed serving openapi document, found paths %v", paths)
+	}
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/docs/ui", nil))
+	page, _ := io.ReadAll(w.Result().Body)
+	if !strings.Contains(string(page), "data-url=\"/docs/openapi.json\"") || !strings.Contains(string(page), "src=\"/docs/ui/assets/docs-ui.js\"") {
+		t.Errorf("failed serving the docs page, found %v", string(page))
+	}
+	// the page loads no external assets and has no inline scripts, so the default csp allows it
+	if strings.Contains(string(page), "http") || strings.Contains(string(page), "<script>") {
+		t.Errorf("expected the docs page to load its assets from the app only")
+	}
+	assets := map[string]string{
+		"/docs/ui/assets/docs-ui.js":  "text/javascript; charset=utf-8",
+		"/docs/ui/assets/docs-ui.css": "text/css; charset=utf-8",
+	}
+	for asset, ct := range assets {
+		w = httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest("GET", asset, nil))
+		if w.Code != http.StatusOK || w.Header().Get(CONTENT_TYPE) != ct || w.Body.Len() == 0 {
+			t.Errorf("failed serving the docs asset %v, found %v %v", asset, w.Code, w.Header())
+		}
+	}
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/docs/ui/assets/missing.js", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected a missing docs asset to get 404, found %v", w.Code)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build redis
+
+package core
+
+// the tests of the redis store run against a real redis server, e.g.
+// REDIS_HOST=localhost REDIS_PORT=6379 REDIS_DB=0 go test -tags redis -run Redis ./...
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestRedisRateLimitStore(t *testing.T) (*RedisRateLimitStore, func(key string) string) {
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("REDIS_HOST is not set")
+	}
+	if os.Getenv("REDIS_DB") == "" {
+		t.Setenv("REDIS_DB", "0")
+	}
+	cache := NewCache(CacheConfig{EnableCache: true})
+	prefix := "ratelimit-test:" + uuid.NewString() + ":"
+	t.Cleanup(func() {
+		keys, _ := cache.redis.Keys(context.Background(), prefix+"*").Result()
+		if len(keys) > 0 {
+			cache.redis.Del(context.Background(), keys...)
+		}
+		cache.redis.Close()
+	})
+	return NewRedisRateLimitStore(cache), func(key string) string { return prefix + key }
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	store, key := newTestRedisRateLimitStore(t)
+	for _, algorithm := range []string{RATE_LIMIT_SLIDING_WINDOW, RATE_LIMIT_TOKEN_BUCKET} {
+		k := key(algorithm + ":10.0.0.1")
+		for i, remaining := range []int{2, 1, 0} {
+			res, err := store.Take(k, algorithm, 3, time.Minute)
+			if err != nil {
+				t.Fatalf("%v: failed taking the limit: %v", algorithm, err)
+			}
+			if !res.Allowed || res.Limit != 3 || res.Remaining != remaining {
+				t.Errorf("%v: failed allowing request %v, found %+v", algorithm, i, res)
+			}
+		}
+		res, err := store.Take(k, algorithm, 3, time.Minute)
+		if err != nil {
+			t.Fatalf("%v: failed taking the limit: %v", algorithm, err)
+		}
+		if res.Allowed || res.Remaining != 0 || res.RetryAfter <= 0 || res.RetryAfter > time.Minute {
+			t.Errorf("%v: failed limiting the request, found %+v", algorithm, res)
+		}
+		res, err = store.Take(key(algorithm+":10.0.0.2"), algorithm, 3, time.Minute)
+		if err != nil || !res.Allowed || res.Remaining != 2 {
+			t.Errorf("%v: expected the requests of another key to be counted separately, found %+v %v", algorithm, res, err)
+		}
+	}
+}
+
+func TestRedisRateLimitStoreRefill(t *testing.T) {
+	store, key := newTestRedisRateLimitStore(t)
+	window := 300 * time.Millisecond
+	for _, algorithm := range []string{RATE_LIMIT_SLIDING_WINDOW, RATE_LIMIT_TOKEN_BUCKET} {
+		k := key(algorithm)
+		if res, err := store.Take(k, algorithm, 1, window); err != nil || !res.Allowed {
+			t.Fatalf("%v: failed allowing the first request, found %+v %v", algorithm, res, err)
+		}
+		if res, err := store.Take(k, algorithm, 1, window); err != nil || res.Allowed {
+			t.Fatalf("%v: failed limiting the second request, found %+v %v", algorithm, res, err)
+		}
+		// the sliding window counts the previous window until it slides out completely
+		time.Sleep(2*window + 50*time.Millisecond)
+		if res, err := store.Take(k, algorithm, 1, window); err != nil || !res.Allowed {
+			t.Errorf("%v: expected the limit to be refilled after the window, found %+v %v", algorithm, res, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryRateLimitStore keeps the counters in memory, the counters are not shared between the instances of the app
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type rateLimitEntry struct {
+	// the fixed window of the sliding window algorithm and its counts
+	win  int64
+	curr int
+	prev int
+	// the tokens of the token bucket algorithm and the time they were counted at
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: map[string]*rateLimitEntry{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, algorithm string, limit int, window time.Duration) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expiresAt) {
+		e = &rateLimitEntry{
+			win:       -1,
+			tokens:    float64(limit),
+			updatedAt: now,
+		}
+		s.entries[key] = e
+	}
+	var res RateLimitResult
+	if algorithm == RATE_LIMIT_TOKEN_BUCKET {
+		e.tokens, res = tokenBucket(limit, window, e.tokens, now.Sub(e.updatedAt))
+		e.updatedAt = now
+		e.expiresAt = now.Add(window)
+		return res, nil
+	}
+	win := now.UnixNano() / int64(window)
+	switch {
+	case e.win == win-1:
+		e.prev = e.curr
+		e.curr = 0
+	case e.win != win:
+		e.prev = 0
+		e.curr = 0
+	}
+	e.win = win
+	e.curr, res = slidingWindow(limit, window, e.curr, e.prev, time.Duration(now.UnixNano()-win*int64(window)))
+	e.expiresAt = now.Add(2 * window)
+	return res, nil
+}
+
+// sweep removes the expired entries, it runs at most once a minute
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// RedisRateLimitStore keeps the counters in redis through the app's cache, the counters are
+// shared between the instances of the app and updated atomically by lua scripts
+type RedisRateLimitStore struct {
+	cache *Cache
+}
+
+func NewRedisRateLimitStore(cache *Cache) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		cache: cache,
+	}
+}
+
+// the scripts use the time of the redis server so the instances of the app share the same clock,
+// they apply the same rules as slidingWindow() and tokenBucket() and return the state before the
+// request is counted, the result is built from it by the same functions the memory store uses
+var slidingWindowScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local win = math.floor(now / window)
+local data = redis.call('HMGET', KEYS[1], 'win', 'curr', 'prev')
+local w = tonumber(data[1])
+local curr = tonumber(data[2]) or 0
+local prev = tonumber(data[3]) or 0
+if w == win - 1 then
+	prev = curr
+	curr = 0
+elseif w ~= win then
+	prev = 0
+	curr = 0
+end
+local elapsed = now - win * window
+local counted = curr
+if prev * (1 - elapsed / window) + curr < limit then
+	counted = curr + 1
+end
+redis.call('HSET', KEYS[1], 'win', win, 'curr', counted, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], math.ceil(window * 2 / 1000))
+return {curr, prev, elapsed}
+`)
+
+var tokenBucketScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or limit
+local ts = tonumber(data[2]) or now
+tokens = math.min(limit, tokens + (now - ts) * limit / window)
+local left = tokens
+if tokens >= 1 then
+	left = tokens - 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(left), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(window / 1000))
+return tostring(tokens)
+`)
+
+func (s *RedisRateLimitStore) Take(key string, algorithm string, limit int, window time.Duration) (RateLimitResult, error) {
+	ctx := context.Background()
+	args := []interface{}{window.Microseconds(), limit}
+	if algorithm == RATE_LIMIT_TOKEN_BUCKET {
+		v, err := tokenBucketScript.Run(ctx, s.cache.redis, []string{key}, args...).Text()
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		tokens, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("invalid token bucket state %v: %v", v, err)
+		}
+		_, res := tokenBucket(limit, window, tokens, 0)
+		return res, nil
+	}
+	v, err := slidingWindowScript.Run(ctx, s.cache.redis, []string{key}, args...).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(v) != 3 {
+		return RateLimitResult{}, fmt.Errorf("invalid sliding window state %v", v)
+	}
+	_, res := slidingWindow(limit, window, int(v[0]), int(v[1]), time.Duration(v[2])*time.Microsecond)
+	return res, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RateLimitOptions struct {
+	// the number of requests allowed in the window, with the token bucket it's the bucket's capacity
+	Limit int
+	// the window the limit applies to, e.g. time.Minute, with the token bucket
+	// the bucket gets refilled at the rate of Limit tokens per Window
+	Window time.Duration
+	// RATE_LIMIT_SLIDING_WINDOW or RATE_LIMIT_TOKEN_BUCKET, default is the sliding window
+	Algorithm string
+	// returns the key the requests are counted by, default is c.ClientIP(), set the trusted proxies
+	// of the app when it's behind a proxy, e.g. the id of the authenticated user for per user limits
+	KeyFunc func(c *Context) string
+	// the store of the counters, default is a memory store, use the redis
+	// store to share the counters between the instances of the app
+	Store RateLimitStore
+	// separates the counters of the limiters that share a store,
+	// default is based on the algorithm, the limit and the window
+	Prefix string
+}
+
+// RateLimitResult is the state of a key's limit after a request is counted
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// the time until the limit is fully reset
+	Reset time.Duration
+	// the time until the next request is allowed, set when the request is not allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts the requests of the keys
+type RateLimitStore interface {
+	Take(key string, algorithm string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimit returns a middleware that limits the rate of the requests, the requests over the limit
+// get a 429 response through the app's error handler, the responses get the X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset headers and the Retry-After header when limited
+func RateLimit(opts RateLimitOptions) Middleware {
+	if opts.Limit < 1 || opts.Window <= 0 {
+		panic("rate limit requires a positive limit and window")
+	}
+	if opts.Algorithm == "" {
+		opts.Algorithm = RATE_LIMIT_SLIDING_WINDOW
+	}
+	if opts.Algorithm != RATE_LIMIT_SLIDING_WINDOW && opts.Algorithm != RATE_LIMIT_TOKEN_BUCKET {
+		panic(fmt.Sprintf("unsupported rate limit algorithm %v", opts.Algorithm))
+	}
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = func(c *Context) string {
+			return c.ClientIP()
+		}
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateLimitStore()
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = fmt.Sprintf("ratelimit:%v:%v:%v:", opts.Algorithm, opts.Limit, opts.Window.Milliseconds())
+	}
+	return func(c *Context) {
+		res, err := opts.Store.Take(opts.Prefix+opts.KeyFunc(c), opts.Algorithm, opts.Limit, opts.Window)
+		if err != nil {
+			// the requests are allowed when the store is not available
+			c.requestLogger().Error(fmt.Sprintf("error taking the rate limit: %v", err))
+			c.Next()
+			return
+		}
+		c.Response.SetHeader("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Response.SetHeader("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Response.SetHeader("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			c.Response.SetHeader("Retry-After", strconv.Itoa(int(math.Max(1, float64(ceilSeconds(res.RetryAfter))))))
+			c.handleError(NewHTTPError(http.StatusTooManyRequests, "Too Many Requests"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// slidingWindow estimates the requests in the last window from the counts of the current and the
+// previous fixed windows, the previous count is weighted by how much it overlaps the sliding window,
+// it returns the counts after the request and the result
+func slidingWindow(limit int, window time.Duration, curr int, prev int, elapsed time.Duration) (int, RateLimitResult) {
+	w := float64(window)
+	weight := 1 - float64(elapsed)/w
+	estimate := float64(prev)*weight + float64(curr)
+	res := RateLimitResult{
+		Limit: limit,
+		Reset: window - elapsed,
+	}
+	if estimate < float64(limit) {
+		curr = curr + 1
+		estimate = estimate + 1
+		res.Allowed = true
+	}
+	res.Remaining = int(math.Max(0, math.Floor(float64(limit)-estimate)))
+	if res.Allowed {
+		return curr, res
+	}
+	if curr < limit {
+		// the weight of the previous window drops enough before the current window ends
+		res.RetryAfter = time.Duration(w*(1-float64(limit-curr)/float64(prev))) - elapsed
+	} else {
+		// the current window becomes the previous one and its weight has to drop
+		res.RetryAfter = window - elapsed + time.Duration(w*(1-float64(limit)/float64(curr)))
+	}
+	if res.RetryAfter < 0 {
+		res.RetryAfter = 0
+	}
+	return curr, res
+}
+
+// tokenBucket refills the bucket for the elapsed time and takes a token for the request,
+// it returns the tokens left and the result
+func tokenBucket(limit int, window time.Duration, tokens float64, elapsed time.Duration) (float64, RateLimitResult) {
+	rate := float64(limit) / float64(window)
+	tokens = math.Min(float64(limit), tokens+float64(elapsed)*rate)
+	res := RateLimitResult{
+		Limit: limit,
+	}
+	if tokens >= 1 {
+		tokens = tokens - 1
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - tokens) / rate))
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = time.Duration(math.Ceil((float64(limit) - tokens) / rate))
+	return tokens, res
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
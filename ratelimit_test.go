@@ -0,0 +1,118 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	curr, res := slidingWindow(10, time.Minute, 0, 10, 30*time.Second)
+	if !res.Allowed || curr != 1 || res.Remaining != 4 || res.Reset != 30*time.Second {
+		t.Errorf("failed counting the request in the sliding window, found %v %+v", curr, res)
+	}
+	curr, res = slidingWindow(10, time.Minute, 5, 10, 15*time.Second)
+	if res.Allowed || curr != 5 || res.Remaining != 0 || res.RetryAfter != 15*time.Second {
+		t.Errorf("failed limiting the request in the sliding window, found %v %+v", curr, res)
+	}
+	_, res = slidingWindow(10, time.Minute, 10, 0, 20*time.Second)
+	if res.Allowed || res.RetryAfter != 40*time.Second {
+		t.Errorf("failed limiting the request in the sliding window, found %+v", res)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	tokens, res := tokenBucket(2, time.Second, 2, 0)
+	if !res.Allowed || tokens != 1 || res.Remaining != 1 || res.Reset != 500*time.Millisecond {
+		t.Errorf("failed taking a token, found %v %+v", tokens, res)
+	}
+	tokens, _ = tokenBucket(2, time.Second, tokens, 0)
+	tokens, res = tokenBucket(2, time.Second, tokens, 0)
+	if res.Allowed || tokens != 0 || res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("failed limiting the request, found %v %+v", tokens, res)
+	}
+	tokens, res = tokenBucket(2, time.Second, tokens, 250*time.Millisecond)
+	if res.Allowed || tokens != 0.5 || res.RetryAfter != 250*time.Millisecond {
+		t.Errorf("failed refilling the bucket, found %v %+v", tokens, res)
+	}
+	tokens, res = tokenBucket(2, time.Second, tokens, 10*time.Second)
+	if !res.Allowed || tokens != 1 {
+		t.Errorf("failed capping the bucket, found %v %+v", tokens, res)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	for _, algorithm := range []string{RATE_LIMIT_SLIDING_WINDOW, RATE_LIMIT_TOKEN_BUCKET} {
+		app := createNewApp(t)
+		loggr = logger.NewLogger(&logger.LogNullDriver{})
+		now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		store := NewMemoryRateLimitStore()
+		store.now = func() time.Time { return now }
+		r := NewRouter()
+		r.Post("/login", Handler(func(c *Context) *Response {
+			return c.Response.Json("{}")
+		}), RateLimit(RateLimitOptions{Limit: 2, Window: time.Minute, Algorithm: algorithm, Store: store}))
+		hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+		serve := func(ip string) *http.Response {
+			req := httptest.NewRequest("POST", "/login", nil)
+			req.RemoteAddr = ip + ":1234"
+			req.Header.Set("Accept", CONTENT_TYPE_JSON)
+			w := httptest.NewRecorder()
+			hr.ServeHTTP(w, req)
+			return w.Result()
+		}
+		for i, remaining := range []string{"1", "0"} {
+			rsp := serve("10.0.0.1")
+			if rsp.StatusCode != http.StatusOK || rsp.Header.Get("X-RateLimit-Limit") != "2" || rsp.Header.Get("X-RateLimit-Remaining") != remaining {
+				t.Errorf("%v: failed allowing request %v, found %v %v", algorithm, i, rsp.StatusCode, rsp.Header)
+			}
+		}
+		rsp := serve("10.0.0.1")
+		b, _ := io.ReadAll(rsp.Body)
+		if rsp.StatusCode != http.StatusTooManyRequests || rsp.Header.Get("Retry-After") == "" || !strings.Contains(string(b), "Too Many Requests") {
+			t.Errorf("%v: failed limiting the request, found %v %v %v", algorithm, rsp.StatusCode, string(b), rsp.Header)
+		}
+		if rsp := serve("10.0.0.2"); rsp.StatusCode != http.StatusOK {
+			t.Errorf("%v: expected the requests of another client to be allowed", algorithm)
+		}
+		now = now.Add(2 * time.Minute)
+		if rsp := serve("10.0.0.1"); rsp.StatusCode != http.StatusOK {
+			t.Errorf("%v: expected the requests to be allowed after the window", algorithm)
+		}
+	}
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	r := NewRouter()
+	r.Post("/login", Handler(func(c *Context) *Response {
+		return c.Response.Json("{}")
+	}), RateLimit(RateLimitOptions{Limit: 1, Window: time.Minute}))
+	app.SetTrustedProxies("10.0.0.0/8")
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	serve := func(forwardedFor string) int {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w.Code
+	}
+	if serve("198.51.100.1") != http.StatusOK || serve("198.51.100.2") != http.StatusOK {
+		t.Errorf("expected the clients behind the proxy to be limited separately")
+	}
+	if code := serve("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the client behind the proxy to be limited, found %v", code)
+	}
+}
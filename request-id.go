@@ -0,0 +1,73 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"github.com/gocondor/core/logger"
+	"github.com/google/uuid"
+)
+
+const maxRequestIDLength = 128
+
+type RequestIDOptions struct {
+	// the header the id is read from and echoed in, default is X-Request-ID
+	Header string
+	// generates the ids of the requests that don't have one, default is uuid v4
+	Generator func() string
+}
+
+// RequestID returns a middleware that takes the id of the request from the request header, or
+// generates one, the id is echoed in the response header, returned by c.GetRequestID(), and
+// prefixes the lines logged with c.GetLogger() during the request
+func RequestID(opts ...RequestIDOptions) Middleware {
+	var opt RequestIDOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Header == "" {
+		opt.Header = "X-Request-ID"
+	}
+	if opt.Generator == nil {
+		opt.Generator = uuid.NewString
+	}
+	return func(c *Context) {
+		id := c.Request.httpRequest.Header.Get(opt.Header)
+		if !validRequestID(id) {
+			id = opt.Generator()
+		}
+		c.requestID = id
+		c.logger = nil
+		c.Response.SetHeader(opt.Header, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the id of the request, it's empty if the request id middleware doesn't run for the request
+func (c *Context) GetRequestID() string {
+	c.checkReleased()
+	return c.requestID
+}
+
+// validRequestID reports whether the id sent by the client is safe to log and echo,
+// ids with control characters could be used to forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestLogger returns the logger of the request, or the app's logger for contexts without one
+func (c *Context) requestLogger() *logger.Logger {
+	if c.GetLogger != nil {
+		return c.GetLogger()
+	}
+	return loggr
+}
@@ -0,0 +1,71 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestRequestID(t *testing.T) {
+	app := createNewApp(t)
+	path := filepath.Join(t.TempDir(), uuid.NewString())
+	loggr = logger.NewLogger(logger.LogFileDriver{FilePath: path})
+	t.Cleanup(func() {
+		logger.CloseLogsFile()
+	})
+	r := NewRouter()
+	r.Get("/users", Handler(func(c *Context) *Response {
+		c.GetLogger().Info("listing " + c.Request.httpRequest.URL.Query().Get("n"))
+		return c.Response.Text(c.GetRequestID())
+	}), RequestID())
+	r.Get("/plain", Handler(func(c *Context) *Response {
+		c.GetLogger().Info("plain request")
+		return c.Response.Text(c.GetRequestID())
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	req := httptest.NewRequest("GET", "/users?n=1", nil)
+	req.Header.Set("X-Request-ID", "client-id-1")
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, req)
+	if w.Header().Get("X-Request-ID") != "client-id-1" || w.Body.String() != "client-id-1" {
+		t.Errorf("failed keeping the request id of the client, found %v", w.Header())
+	}
+
+	req = httptest.NewRequest("GET", "/users?n=2", nil)
+	req.Header.Set("X-Request-ID", "forged\nerror: line")
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, req)
+	generated := w.Header().Get("X-Request-ID")
+	if _, err := uuid.Parse(generated); err != nil || w.Body.String() != generated {
+		t.Errorf("expected an invalid request id to be replaced by a generated one, found %v", generated)
+	}
+
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/plain", nil))
+	if w.Header().Get("X-Request-ID") != "" || w.Body.String() != "" {
+		t.Errorf("expected no request id without the middleware")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed reading the logs file: %v", err)
+	}
+	logs := string(b)
+	if !strings.Contains(logs, "[client-id-1] listing 1") || !strings.Contains(logs, "["+generated+"] listing 2") {
+		t.Errorf("failed prefixing the log lines with the request id, found %v", logs)
+	}
+	if strings.Contains(logs, "] plain request") {
+		t.Errorf("expected the log lines of a request without an id to have no prefix, found %v", logs)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResourceIndex handles GET /photos
+type ResourceIndex interface {
+	Index(c *Context) *Response
+}
+
+// ResourceCreate handles GET /photos/create
+type ResourceCreate interface {
+	Create(c *Context) *Response
+}
+
+// ResourceStore handles POST /photos
+type ResourceStore interface {
+	Store(c *Context) *Response
+}
+
+// ResourceShow handles GET /photos/:id
+type ResourceShow interface {
+	Show(c *Context) *Response
+}
+
+// ResourceEdit handles GET /photos/:id/edit
+type ResourceEdit interface {
+	Edit(c *Context) *Response
+}
+
+// ResourceUpdate handles PUT and PATCH /photos/:id
+type ResourceUpdate interface {
+	Update(c *Context) *Response
+}
+
+// ResourceDestroy handles DELETE /photos/:id
+type ResourceDestroy interface {
+	Destroy(c *Context) *Response
+}
+
+type ResourceOptions struct {
+	// registers only the given actions
+	Only []string
+	// skips the given actions
+	Except []string
+	// skips the actions that render html forms (create and edit)
+	APIOnly bool
+	// the name of the path param, default is "id"
+	Param string
+	// middlewares attached to specific actions, e.g. {RESOURCE_STORE: {auth}}
+	Middlewares map[string][]Middleware
+}
+
+// Resource registers the crud routes of the given controller, only the actions the controller implements get registered
+func (r *Router) Resource(path string, controller interface{}, opts ...ResourceOptions) *Router {
+	var opt ResourceOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	param := opt.Param
+	if param == "" {
+		param = "id"
+	}
+	itemPath := joinPaths(path, ":"+param)
+	name := resourceName(path)
+	registered := false
+	register := func(action string, method string, p string, h Handler) {
+		if !opt.wants(action) {
+			return
+		}
+		r.addRoute(method, p, h, opt.Middlewares[action])
+		r.Name(fmt.Sprintf("%v.%v", name, action))
+		r.setHandlerName(controller, action)
+		registered = true
+	}
+	if c, ok := controller.(ResourceIndex); ok {
+		register(RESOURCE_INDEX, GET, path, c.Index)
+	}
+	if c, ok := controller.(ResourceCreate); ok {
+		register(RESOURCE_CREATE, GET, joinPaths(path, "create"), c.Create)
+	}
+	if c, ok := controller.(ResourceStore); ok {
+		register(RESOURCE_STORE, POST, path, c.Store)
+	}
+	if c, ok := controller.(ResourceShow); ok {
+		register(RESOURCE_SHOW, GET, itemPath, c.Show)
+	}
+	if c, ok := controller.(ResourceEdit); ok {
+		register(RESOURCE_EDIT, GET, joinPaths(itemPath, "edit"), c.Edit)
+	}
+	if c, ok := controller.(ResourceUpdate); ok {
+		register(RESOURCE_UPDATE, PUT, itemPath, c.Update)
+		if opt.wants(RESOURCE_UPDATE) {
+			r.addRoute(PATCH, itemPath, c.Update, opt.Middlewares[RESOURCE_UPDATE])
+			r.setHandlerName(controller, RESOURCE_UPDATE)
+		}
+	}
+	if c, ok := controller.(ResourceDestroy); ok {
+		register(RESOURCE_DESTROY, DELETE, itemPath, c.Destroy)
+	}
+	if !registered {
+		panic(fmt.Sprintf("resource %v has no actions to register", path))
+	}
+	return r
+}
+
+// setHandlerName sets the name of the last added route's handler to the controller's method name
+func (r *Router) setHandlerName(controller interface{}, action string) {
+	name := fmt.Sprintf("%T.%v%v", controller, strings.ToUpper(action[0:1]), action[1:])
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	for _, route := range r.lastRoutes("") {
+		route.handlerName = name
+	}
+}
+
+func (o ResourceOptions) wants(action string) bool {
+	if o.APIOnly && (action == RESOURCE_CREATE || action == RESOURCE_EDIT) {
+		return false
+	}
+	if len(o.Only) > 0 && !containsString(o.Only, action) {
+		return false
+	}
+	return !containsString(o.Except, action)
+}
+
+// resourceName converts the resource path to a route name prefix, e.g. /users/:user/photos -> users.photos
+func resourceName(path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || isParamSegment(segment) {
+			continue
+		}
+		parts = append(parts, segment)
+	}
+	return strings.Join(parts, ".")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type testPhotosController struct{}
+
+func (tc testPhotosController) Index(c *Context) *Response {
+	return c.Response.Text("index")
+}
+
+func (tc testPhotosController) Create(c *Context) *Response {
+	return c.Response.Text("create")
+}
+
+func (tc testPhotosController) Show(c *Context) *Response {
+	return c.Response.Text("show " + c.CastToString(c.GetPathParam("id")))
+}
+
+func (tc testPhotosController) Update(c *Context) *Response {
+	return c.Response.Text("update")
+}
+
+type testCommentsController struct{}
+
+func (tc testCommentsController) Store(c *Context) *Response {
+	return c.Response.Text("store")
+}
+
+func TestResource(t *testing.T) {
+	r := NewRouter()
+	r.Resource("/photos", testPhotosController{})
+	routes := r.GetRoutes()
+	if len(routes) != 5 {
+		t.Fatalf("failed testing resource, expected 5 routes found %v", len(routes))
+	}
+	expected := [][]string{
+		{GET, "/photos", "photos.index"},
+		{GET, "/photos/create", "photos.create"},
+		{GET, "/photos/:id", "photos.show"},
+		{PUT, "/photos/:id", "photos.update"},
+		{PATCH, "/photos/:id", ""},
+	}
+	for i, e := range expected {
+		if routes[i].Method != e[0] || routes[i].Path != e[1] || routes[i].Name != e[2] {
+			t.Errorf("failed testing resource route %v %v", e[0], e[1])
+		}
+	}
+}
+
+func TestResourceOptions(t *testing.T) {
+	r := NewRouter()
+	mw := Middleware(func(c *Context) { c.Next() })
+	r.Group("/users/:user").Resource("/photos", testPhotosController{}, ResourceOptions{
+		APIOnly:     true,
+		Except:      []string{RESOURCE_UPDATE},
+		Param:       "photo",
+		Middlewares: map[string][]Middleware{RESOURCE_SHOW: {mw}},
+	})
+	routes := r.GetRoutes()
+	if len(routes) != 2 {
+		t.Fatalf("failed testing resource options, expected 2 routes found %v", len(routes))
+	}
+	if routes[0].Path != "/users/:user/photos" || routes[0].Name != "photos.index" {
+		t.Errorf("failed testing resource options")
+	}
+	if routes[1].Path != "/users/:user/photos/:photo" || len(routes[1].Middlewares) != 1 {
+		t.Errorf("failed testing resource options")
+	}
+}
+
+func TestResourceWithoutActions(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expecting resource without actions to panic")
+		}
+	}()
+	NewRouter().Resource("/comments", testCommentsController{}, ResourceOptions{Only: []string{RESOURCE_INDEX}})
+}
+
+func TestResourceDispatch(t *testing.T) {
+	app := createNewApp(t)
+	r := NewRouter()
+	r.Resource("/photos", testPhotosController{})
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	for path, expected := range map[string]string{
+		"/photos":        "index",
+		"/photos/create": "create",
+		"/photos/5":      "show 5",
+	} {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		b, _ := io.ReadAll(w.Result().Body)
+		if string(b) != expected {
+			t.Errorf("failed dispatching %v, expected %v found %v", path, expected, string(b))
+		}
+	}
+}
+
+func TestResourceWithAny(t *testing.T) {
+	app := createNewApp(t)
+	r := NewRouter()
+	r.Resource("/photos", testPhotosController{})
+	r.Any("/photos/recent", Handler(func(c *Context) *Response {
+		return c.Response.Text("recent")
+	}))
+	r.Get("/albums/:id", Handler(func(c *Context) *Response {
+		return c.Response.Text("album")
+	}))
+	r.Head("/albums/latest", Handler(func(c *Context) *Response {
+		return c.Response.SetHeader("X-Album", "latest")
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	for _, tt := range []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/photos/5", "show 5"},
+		{"GET", "/photos/recent", "recent"},
+		{"POST", "/photos/recent", "recent"},
+		{"GET", "/albums/latest", "album"},
+	} {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if b, _ := io.ReadAll(w.Result().Body); string(b) != tt.expected {
+			t.Errorf("failed dispatching %v %v, expected %v found %v", tt.method, tt.path, tt.expected, string(b))
+		}
+	}
+	for path, length := range map[string]string{"/photos/5": "6", "/photos/recent": "6", "/albums/7": "5"} {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest("HEAD", path, nil))
+		if w.Code != http.StatusOK || w.Header().Get("Content-Length") != length {
+			t.Errorf("failed answering the HEAD request of %v, found %v %v", path, w.Code, w.Header())
+		}
+	}
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("HEAD", "/albums/latest", nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-Album") != "latest" {
+		t.Errorf("expected the explicit HEAD route to answer, found %v %v", w.Code, w.Header())
+	}
+}
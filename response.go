@@ -7,6 +7,7 @@ package core
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type Response struct {
@@ -17,7 +18,11 @@ type Response struct {
 	overrideContentType string
 	isTerminated        bool
 	redirectTo          string
-	HttpResponseWriter  http.ResponseWriter
+	// writes the body directly to the http response writer instead of the buffered body, e.g. for files
+	stream             func(w http.ResponseWriter, r *http.Request)
+	HttpResponseWriter http.ResponseWriter
+	// the response is returned to the pool with its context after the request is finished
+	released bool
 }
 
 type header struct {
@@ -27,7 +32,7 @@ type header struct {
 
 // TODO add doc
 func (rs *Response) Any(body any) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		rs.contentType = CONTENT_TYPE_HTML
 		rs.body = []byte(rs.castBasicVarsToString(body))
 	}
@@ -37,7 +42,7 @@ func (rs *Response) Any(body any) *Response {
 
 // TODO add doc
 func (rs *Response) Json(body string) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		rs.contentType = CONTENT_TYPE_JSON
 		rs.body = []byte(body)
 	}
@@ -46,7 +51,7 @@ func (rs *Response) Json(body string) *Response {
 
 // TODO add doc
 func (rs *Response) Text(body string) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		rs.contentType = CONTENT_TYPE_TEXT
 		rs.body = []byte(body)
 	}
@@ -55,7 +60,7 @@ func (rs *Response) Text(body string) *Response {
 
 // TODO add doc
 func (rs *Response) HTML(body string) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		rs.contentType = CONTENT_TYPE_HTML
 		rs.body = []byte(body)
 	}
@@ -64,7 +69,7 @@ func (rs *Response) HTML(body string) *Response {
 
 // TODO add doc
 func (rs *Response) SetStatusCode(code int) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		rs.statusCode = code
 	}
 
@@ -73,7 +78,7 @@ func (rs *Response) SetStatusCode(code int) *Response {
 
 // TODO add doc
 func (rs *Response) SetContentType(c string) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		rs.overrideContentType = c
 	}
 
@@ -82,7 +87,7 @@ func (rs *Response) SetContentType(c string) *Response {
 
 // TODO add doc
 func (rs *Response) SetHeader(key string, val string) *Response {
-	if rs.isTerminated == false {
+	if rs.writable() {
 		h := header{
 			key: key,
 			val: val,
@@ -96,7 +101,138 @@ func (rs *Response) ForceSendResponse() {
 	rs.isTerminated = true
 }
 
+// GetStatusCode returns the status code the response is sent with
+func (rs *Response) GetStatusCode() int {
+	if rs.redirectTo != "" {
+		return http.StatusPermanentRedirect
+	}
+	if rs.statusCode == 0 {
+		return http.StatusOK
+	}
+	return rs.statusCode
+}
+
+// GetBody returns the body of the response, it's nil for streamed responses like files
+func (rs *Response) GetBody() []byte {
+	return rs.body
+}
+
+// SetBody replaces the body of the response and keeps its content type
+func (rs *Response) SetBody(body []byte) *Response {
+	if rs.writable() {
+		rs.body = body
+	}
+	return rs
+}
+
+// GetContentType returns the content type the response is sent with
+func (rs *Response) GetContentType() string {
+	if rs.overrideContentType != "" {
+		return rs.overrideContentType
+	}
+	if rs.contentType != "" {
+		return rs.contentType
+	}
+	return CONTENT_TYPE_HTML
+}
+
+// GetHeader returns the first value set for the header with the given key
+func (rs *Response) GetHeader(key string) string {
+	key = http.CanonicalHeaderKey(key)
+	for _, h := range rs.headers {
+		if http.CanonicalHeaderKey(h.key) == key {
+			return h.val
+		}
+	}
+	return ""
+}
+
+// GetHeaders returns the headers set on the response
+func (rs *Response) GetHeaders() http.Header {
+	res := http.Header{}
+	for _, h := range rs.headers {
+		res.Add(h.key, h.val)
+	}
+	return res
+}
+
+// DelHeader removes the values set for the header with the given key
+func (rs *Response) DelHeader(key string) *Response {
+	if rs.writable() {
+		rs.delHeader(key)
+	}
+	return rs
+}
+
+func (rs *Response) delHeader(key string) {
+	key = http.CanonicalHeaderKey(key)
+	headers := rs.headers[:0]
+	for _, h := range rs.headers {
+		if http.CanonicalHeaderKey(h.key) != key {
+			headers = append(headers, h)
+		}
+	}
+	rs.headers = headers
+}
+
+// forceHeader adds a header even if the response is not writable, it's used by the middlewares
+// that change the response after the handler, so the responses sent with ForceSendResponse() get the header too
+func (rs *Response) forceHeader(key string, val string) {
+	rs.headers = append(rs.headers, header{key: key, val: val})
+}
+
+// SetETag sets the strong etag of the response, e.g. a hash or a version of the resource,
+// the value is quoted if it's not quoted already
+func (rs *Response) SetETag(etag string) *Response {
+	if rs.writable() {
+		rs.delHeader("ETag")
+		rs.headers = append(rs.headers, header{key: "ETag", val: quoteETag(etag)})
+	}
+	return rs
+}
+
+// SetWeakETag sets the weak etag of the response, for resources that are equivalent
+// but not identical byte for byte, e.g. when they are rendered with a timestamp
+func (rs *Response) SetWeakETag(etag string) *Response {
+	if rs.writable() {
+		rs.delHeader("ETag")
+		rs.headers = append(rs.headers, header{key: "ETag", val: "W/" + quoteETag(etag)})
+	}
+	return rs
+}
+
+// SetLastModified sets the Last-Modified header of the response, with the etag
+// middleware the requests with If-Modified-Since get 304 if it's not modified since
+func (rs *Response) SetLastModified(t time.Time) *Response {
+	if rs.writable() {
+		rs.delHeader("Last-Modified")
+		rs.headers = append(rs.headers, header{key: "Last-Modified", val: t.UTC().Format(http.TimeFormat)})
+	}
+	return rs
+}
+
+// IsStreamed checks whether the body is written directly to the client, e.g. for files,
+// the body of streamed responses is not accessible
+func (rs *Response) IsStreamed() bool {
+	return rs.stream != nil
+}
+
+// writable checks whether the response can still be changed, it panics if the response is released
+func (rs *Response) writable() bool {
+	if rs.released {
+		panic("the response is used after the request is finished")
+	}
+	return !rs.isTerminated
+}
+
+// Redirect redirects to the given url, or to the url of the route if a route with the given name exists
 func (rs *Response) Redirect(url string) *Response {
+	r := ResolveRouter()
+	if r != nil {
+		if _, ok := r.routeByName(url); ok {
+			return rs.RedirectToRoute(url, nil)
+		}
+	}
 	validator := resolveValidator()
 	v := validator.Validate(map[string]interface{}{
 		"url": url,
@@ -115,6 +251,16 @@ func (rs *Response) Redirect(url string) *Response {
 	return rs
 }
 
+// RedirectToRoute redirects to the url of the route with the given name
+func (rs *Response) RedirectToRoute(name string, params map[string]interface{}) *Response {
+	url, err := ResolveRouter().URL(name, params)
+	if err != nil {
+		panic(fmt.Sprintf("error redirecting to route: %v", err))
+	}
+	rs.redirectTo = url
+	return rs
+}
+
 func (rs *Response) castBasicVarsToString(data interface{}) string {
 	switch dataType := data.(type) {
 	case string:
@@ -179,4 +325,15 @@ func (rs *Response) reset() {
 	rs.overrideContentType = ""
 	rs.isTerminated = false
 	rs.redirectTo = ""
+	rs.stream = nil
+}
+
+// release clears the response including the headers before it's returned to the pool
+func (rs *Response) release() {
+	rs.reset()
+	rs.headers = rs.headers[:0]
+	rs.statusCode = 0
+	rs.contentType = ""
+	rs.HttpResponseWriter = nil
+	rs.released = true
 }
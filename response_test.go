@@ -152,3 +152,44 @@ func TestCastBasicVarToString(t *testing.T) {
 		t.Errorf("failed test cast basic var to string")
 	}
 }
+
+func TestRedirectToRouteName(t *testing.T) {
+	r := NewRouter()
+	r.Get("/users/:id", Handler(func(c *Context) *Response {
+		return nil
+	})).Name("users.show")
+	r.Get("/dashboard", Handler(func(c *Context) *Response {
+		return nil
+	})).Name("dashboard")
+	res := Response{}
+	res.Redirect("dashboard")
+	if res.redirectTo != "/dashboard" {
+		t.Errorf("failed redirecting to route name")
+	}
+	res.RedirectToRoute("users.show", map[string]interface{}{"id": 3})
+	if res.redirectTo != "/users/3" {
+		t.Errorf("failed redirecting to route")
+	}
+}
+
+func TestResponseGetters(t *testing.T) {
+	res := Response{}
+	if res.GetStatusCode() != 200 || res.GetContentType() != CONTENT_TYPE_HTML {
+		t.Errorf("failed getting the default status code and content type")
+	}
+	res.SetStatusCode(201).Json("{}").SetHeader("x-test", "1").SetHeader("X-Test", "2").SetHeader("X-Other", "3")
+	if res.GetStatusCode() != 201 || string(res.GetBody()) != "{}" || res.GetContentType() != CONTENT_TYPE_JSON {
+		t.Errorf("failed getting the response status code, body and content type")
+	}
+	if res.GetHeader("X-TEST") != "1" || len(res.GetHeaders().Values("X-Test")) != 2 {
+		t.Errorf("failed getting the response headers")
+	}
+	res.DelHeader("x-test")
+	if res.GetHeader("X-Test") != "" || res.GetHeader("X-Other") != "3" {
+		t.Errorf("failed deleting the response header")
+	}
+	res.SetBody([]byte("[]"))
+	if string(res.GetBody()) != "[]" || res.GetContentType() != CONTENT_TYPE_JSON || res.IsStreamed() {
+		t.Errorf("failed setting the response body")
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// routeCandidate is a route that is registered on the httprouter under a
+// pattern that might be shared with other routes
+type routeCandidate struct {
+	route Route
+	// params that are part of the shared pattern but are static segments
+	// in the route's path, e.g. /photos/create under /photos/:id
+	fixedParams map[string]string
+	// params that are named differently in the route's path than in the shared pattern,
+	// maps the name in the pattern to the name in the route's path
+	renamedParams map[string]string
+	constraints   map[string]*regexp.Regexp
+}
+
+type hostParamsKey struct{}
+
+type routeEntry struct {
+	method     string
+	pattern    string
+	candidates []*routeCandidate
+}
+
+// match checks whether the request should be dispatched to the candidate,
+// the returned request carries the host params if the route is bound to a host
+func (rc *routeCandidate) match(r *http.Request, ps httprouter.Params) (*http.Request, bool) {
+	for key, val := range rc.fixedParams {
+		if ps.ByName(key) != val {
+			return r, false
+		}
+	}
+	rps := rc.params(ps)
+	for key, re := range rc.constraints {
+		if !re.MatchString(rps.ByName(key)) {
+			return r, false
+		}
+	}
+	if rc.route.version != "" && !rc.matchVersion(r) {
+		return r, false
+	}
+	if rc.route.Host == "" {
+		return r, true
+	}
+	hostParams, ok := matchHost(rc.route.Host, r.Host)
+	if !ok {
+		return r, false
+	}
+	if len(hostParams) != 0 {
+		r = r.WithContext(context.WithValue(r.Context(), hostParamsKey{}, hostParams))
+	}
+	return r, true
+}
+
+// params converts the params of the shared pattern to the params of the route's path
+func (rc *routeCandidate) params(ps httprouter.Params) httprouter.Params {
+	if len(rc.fixedParams) == 0 && len(rc.renamedParams) == 0 {
+		return ps
+	}
+	var res httprouter.Params
+	for _, p := range ps {
+		if _, ok := rc.fixedParams[p.Key]; ok {
+			continue
+		}
+		if name, ok := rc.renamedParams[p.Key]; ok {
+			p.Key = name
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// buildRouteEntries groups the routes by the patterns they get registered with on the httprouter,
+// static path segments that conflict with a param segment of another route are folded into
+// the param segment, this allows defining routes like /photos/create next to /photos/:id,
+// and params named differently at the same position are renamed, this allows /users/:id
+// with a numeric constraint next to /users/:name
+func buildRouteEntries(routes []Route) []*routeEntry {
+	var candidates []*routeCandidate
+	var segments [][]string
+	for _, route := range routes {
+		constraints := map[string]*regexp.Regexp{}
+		for param, pattern := range route.Constraints {
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				panic(fmt.Sprintf("invalid constraint for param %v of route %v: %v", param, route.Path, err))
+			}
+			constraints[param] = re
+		}
+		candidates = append(candidates, &routeCandidate{
+			route:         route,
+			fixedParams:   map[string]string{},
+			renamedParams: map[string]string{},
+			constraints:   constraints,
+		})
+		segments = append(segments, strings.Split(route.Path, "/"))
+	}
+	for pos := 0; ; pos++ {
+		found := false
+		for i, segs := range segments {
+			if len(segs) <= pos {
+				continue
+			}
+			found = true
+			for k, other := range segments {
+				if k == i || candidates[k].route.Method != candidates[i].route.Method {
+					continue
+				}
+				if len(other) <= pos || !isParamSegment(other[pos]) || !sameSegments(segs[:pos], other[:pos]) {
+					continue
+				}
+				if isParamSegment(segs[pos]) {
+					if k > i {
+						// params get renamed to the names used by the routes added before
+						continue
+					}
+					if segs[pos] != other[pos] {
+						candidates[i].renamedParams[other[pos][1:]] = segs[pos][1:]
+						segs[pos] = other[pos]
+					}
+					break
+				}
+				if !strings.HasPrefix(segs[pos], "*") {
+					candidates[i].fixedParams[other[pos][1:]] = segs[pos]
+					segs[pos] = other[pos]
+				}
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	var entries []*routeEntry
+	entriesMap := map[string]*routeEntry{}
+	for i, candidate := range candidates {
+		pattern := strings.Join(segments[i], "/")
+		key := candidate.route.Method + " " + pattern
+		entry, ok := entriesMap[key]
+		if !ok {
+			entry = &routeEntry{
+				method:  candidate.route.Method,
+				pattern: pattern,
+			}
+			entriesMap[key] = entry
+			entries = append(entries, entry)
+		}
+		entry.candidates = append(entry.candidates, candidate)
+	}
+	for _, entry := range entries {
+		entry.sortCandidates()
+	}
+	return entries
+}
+
+// sortCandidates moves the more specific candidates to the front, so they get checked first
+func (re *routeEntry) sortCandidates() {
+	cs := re.candidates
+	for i := 1; i < len(cs); i++ {
+		for j := i; j > 0 && cs[j].specificity() > cs[j-1].specificity(); j-- {
+			cs[j], cs[j-1] = cs[j-1], cs[j]
+		}
+	}
+}
+
+func (rc *routeCandidate) specificity() int {
+	s := len(rc.fixedParams)*10 + len(rc.constraints)
+	if rc.route.version != "" {
+		s = s + 100
+	}
+	if rc.route.Host != "" {
+		s = s + 1000
+	}
+	return s
+}
+
+func (re *routeEntry) isConditional() bool {
+	return len(re.candidates) != 1 || re.candidates[0].specificity() != 0 || len(re.candidates[0].renamedParams) != 0
+}
+
+func (app *App) makeDispatcherHandle(re *routeEntry, notFound http.Handler) httprouter.Handle {
+	var handles []httprouter.Handle
+	for _, c := range re.candidates {
+		handles = append(handles, app.makeRouteHandle(c.route))
+	}
+	if !re.isConditional() {
+		return handles[0]
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		for i, c := range re.candidates {
+			if rr, ok := c.match(r, ps); ok {
+				handles[i](w, rr, c.params(ps))
+				return
+			}
+		}
+		notFound.ServeHTTP(w, r)
+	}
+}
+
+// matchHost matches the request host against a host pattern like {tenant}.example.com
+func matchHost(pattern string, host string) (map[string]string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	patternLabels := strings.Split(pattern, ".")
+	hostLabels := strings.Split(strings.ToLower(host), ".")
+	if len(patternLabels) != len(hostLabels) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, label := range patternLabels {
+		if strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}") {
+			if hostLabels[i] == "" {
+				return nil, false
+			}
+			params[label[1:len(label)-1]] = hostLabels[i]
+			continue
+		}
+		if label != hostLabels[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func hostParamsFromRequest(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(hostParamsKey{}).(map[string]string)
+	return params
+}
+
+func isParamSegment(segment string) bool {
+	return strings.HasPrefix(segment, ":")
+}
+
+func sameSegments(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
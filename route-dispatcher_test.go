@@ -0,0 +1,132 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestBuildRouteEntries(t *testing.T) {
+	h := Handler(func(c *Context) *Response { return nil })
+	routes := []Route{
+		{Method: GET, Path: "/users/:id", Handler: h},
+		{Method: GET, Path: "/users/me", Handler: h},
+		{Method: GET, Path: "/users/me/settings", Handler: h},
+		{Method: POST, Path: "/users/me", Handler: h},
+	}
+	entries := buildRouteEntries(routes)
+	if len(entries) != 3 {
+		t.Fatalf("failed building route entries, expected 3 found %v", len(entries))
+	}
+	if entries[0].pattern != "/users/:id" || len(entries[0].candidates) != 2 {
+		t.Errorf("failed folding static segment into param segment")
+	}
+	if entries[0].candidates[0].route.Path != "/users/me" || entries[0].candidates[0].fixedParams["id"] != "me" {
+		t.Errorf("failed ordering route candidates")
+	}
+	if entries[1].pattern != "/users/:id/settings" || !entries[1].isConditional() {
+		t.Errorf("failed folding static segment into param segment")
+	}
+	if entries[2].pattern != "/users/me" || entries[2].isConditional() {
+		t.Errorf("failed building route entry for a different method")
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	params, ok := matchHost("{tenant}.example.com", "Acme.example.com:8080")
+	if !ok || params["tenant"] != "acme" {
+		t.Errorf("failed matching host")
+	}
+	if _, ok := matchHost("{tenant}.example.com", "example.com"); ok {
+		t.Errorf("failed matching host, expecting no match")
+	}
+	if _, ok := matchHost("admin.example.com", "api.example.com"); ok {
+		t.Errorf("failed matching host, expecting no match")
+	}
+}
+
+func TestDomainDispatch(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	r := NewRouter()
+	r.Domain("admin.example.com").Get("/", Handler(func(c *Context) *Response {
+		return c.Response.Text("admin")
+	}))
+	r.Domain("{tenant}.example.com").Get("/", Handler(func(c *Context) *Response {
+		return c.Response.Text("tenant " + c.CastToString(c.GetHostParam("tenant")))
+	}))
+	r.Get("/", Handler(func(c *Context) *Response {
+		return c.Response.Text("default")
+	}))
+	r.Domain("api.example.com").Get("/users", Handler(func(c *Context) *Response {
+		return c.Response.Text("users")
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	cases := []struct {
+		host     string
+		path     string
+		code     int
+		expected string
+	}{
+		{"admin.example.com", "/", 200, "admin"},
+		{"acme.example.com", "/", 200, "tenant acme"},
+		{"localhost", "/", 200, "default"},
+		{"api.example.com", "/users", 200, "users"},
+		{"admin.example.com", "/users", 404, ""},
+	}
+	for _, cs := range cases {
+		req := httptest.NewRequest("GET", cs.path, nil)
+		req.Host = cs.host
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		rsp := w.Result()
+		b, _ := io.ReadAll(rsp.Body)
+		if rsp.StatusCode != cs.code || (cs.code == 200 && string(b) != cs.expected) {
+			t.Errorf("failed dispatching %v%v, found %v %v", cs.host, cs.path, rsp.StatusCode, string(b))
+		}
+	}
+}
+
+func TestConstraintsDispatch(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	r := NewRouter()
+	r.Get("/posts/:id", Handler(func(c *Context) *Response {
+		return c.Response.Text("by id")
+	})).WhereNumber("id")
+	r.Get("/users/:id", Handler(func(c *Context) *Response {
+		return c.Response.Text("user by id")
+	})).WhereNumber("id")
+	r.Get("/users/:name", Handler(func(c *Context) *Response {
+		return c.Response.Text("user by name")
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	cases := map[string]string{
+		"/posts/12":    "by id",
+		"/posts/hello": "404",
+		"/users/12":    "user by id",
+		"/users/bob":   "user by name",
+	}
+	for path, expected := range cases {
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		rsp := w.Result()
+		b, _ := io.ReadAll(rsp.Body)
+		if expected == "404" {
+			if rsp.StatusCode != 404 {
+				t.Errorf("failed dispatching %v, expecting 404 found %v", path, rsp.StatusCode)
+			}
+			continue
+		}
+		if string(b) != expected {
+			t.Errorf("failed dispatching %v, expected %v found %v", path, expected, string(b))
+		}
+	}
+}
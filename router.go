@@ -4,22 +4,71 @@
 
 package core
 
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const numericPattern string = "[0-9]+"
+const alphaPattern string = "[a-zA-Z]+"
+const uuidPattern string = "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"
+
+// the values of the host placeholders are single labels of the host
+var hostLabelRegex = regexp.MustCompile("^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$")
+
 type Route struct {
-	Method      string
-	Path        string
+	Name   string
+	Method string
+	Host   string
+	Path   string
+	// regex patterns the path params must match, otherwise the route is not matched
+	Constraints map[string]string
 	Handler     Handler
 	Middlewares []Middleware
+	// documentation used to generate the OpenAPI document
+	Doc *RouteDoc
+	// overrides the name reported for the handler, used when the handler is a method value of an interface
+	handlerName string
+	// fallback routes handle the requests that don't match any other route
+	fallback bool
+	// the routes serving the OpenAPI document are excluded from it
+	openAPI bool
+	// the api version requested in the headers that the route is dispatched for, set
+	// for the routes of a version group registered without the version prefix
+	version string
+	// the route is dispatched for the requests with no requested version too
+	defaultVersion bool
+	// the names of the middleware groups and the named middlewares the route uses
+	middlewareNames []string
+	// the names of the middlewares skipped for the route, e.g. a global middleware
+	withoutMiddlewares []string
 }
 
 type Router struct {
-	Routes []Route
+	Routes      []Route
+	prefix      string
+	host        string
+	middlewares []Middleware
+	parent      *Router
+	// the api version of the routes added to the router, and the prefix the version group was created under
+	version        string
+	versionPrefix  string
+	defaultVersion bool
+	// the names of the middleware groups and the named middlewares the routes added to the router use
+	middlewareNames []string
+	// the number of routes added by the last call, e.g. Match adds a route per method
+	lastAdded int
 }
 
 var router *Router
 
 func NewRouter() *Router {
 	router = &Router{
-		[]Route{},
+		Routes: []Route{},
 	}
 	return router
 }
@@ -28,76 +77,326 @@ func ResolveRouter() *Router {
 	return router
 }
 
+// Group creates a sub router, routes added to it get the given prefix and middlewares
+// on top of the prefix and middlewares of the router it was created from
+func (r *Router) Group(prefix string, middlewares ...Middleware) *Router {
+	var mws []Middleware
+	mws = append(mws, r.middlewares...)
+	mws = append(mws, middlewares...)
+	return &Router{
+		prefix:         joinPaths(r.prefix, prefix),
+		host:           r.host,
+		middlewares:    mws,
+		parent:         r,
+		version:        r.version,
+		versionPrefix:  r.versionPrefix,
+		defaultVersion: r.defaultVersion,
+		// copied so the groups added to the sub router don't leak to its siblings
+		middlewareNames: append([]string{}, r.middlewareNames...),
+	}
+}
+
+// Domain creates a sub router for the routes that get dispatched only for requests
+// with a matching Host header, the host pattern can have placeholders like {tenant}.example.com
+// the values of the placeholders are accessible in handlers through c.GetHostParam("tenant")
+func (r *Router) Domain(host string, middlewares ...Middleware) *Router {
+	g := r.Group("", middlewares...)
+	g.host = strings.ToLower(host)
+	return g
+}
+
 func (r *Router) Get(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      GET,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
-	return r
+	return r.addRoute(GET, path, handler, middlewares)
 }
 
 func (r *Router) Post(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      POST,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
-	return r
+	return r.addRoute(POST, path, handler, middlewares)
 }
 
 func (r *Router) Delete(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      DELETE,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
-	return r
+	return r.addRoute(DELETE, path, handler, middlewares)
 }
 
 func (r *Router) Patch(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      PATCH,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
-	return r
+	return r.addRoute(PATCH, path, handler, middlewares)
 }
 
 func (r *Router) Put(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      PUT,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
-	return r
+	return r.addRoute(PUT, path, handler, middlewares)
 }
 
 func (r *Router) Options(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      OPTIONS,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
-	return r
+	return r.addRoute(OPTIONS, path, handler, middlewares)
 }
 
 func (r *Router) Head(path string, handler Handler, middlewares ...Middleware) *Router {
-	r.Routes = append(r.Routes, Route{
-		Method:      HEAD,
-		Path:        path,
-		Handler:     handler,
-		Middlewares: middlewares,
-	})
+	return r.addRoute(HEAD, path, handler, middlewares)
+}
+
+// Fallback registers a handler for the GET and HEAD requests that don't match any other route,
+// the requested path is accessible through c.GetPathParam("path")
+func (r *Router) Fallback(handler Handler, middlewares ...Middleware) *Router {
+	return r.addFallback([]string{GET, HEAD}, "*path", handler, middlewares)
+}
+
+func (r *Router) addFallback(methods []string, path string, handler Handler, middlewares []Middleware) *Router {
+	rt := r.root()
+	n := 0
+	for _, method := range methods {
+		r.addRoute(method, path, handler, middlewares)
+		for _, route := range r.lastRoutes("") {
+			route.fallback = true
+		}
+		n = n + rt.lastAdded
+	}
+	rt.lastAdded = n
+	return r
+}
+
+// Match registers the handler for each of the given http methods
+func (r *Router) Match(methods []string, path string, handler Handler, middlewares ...Middleware) *Router {
+	rt := r.root()
+	n := 0
+	for _, method := range methods {
+		r.addRoute(strings.ToLower(method), path, handler, middlewares)
+		n = n + rt.lastAdded
+	}
+	rt.lastAdded = n
+	return r
+}
+
+// Any registers the handler for all http methods
+func (r *Router) Any(path string, handler Handler, middlewares ...Middleware) *Router {
+	return r.Match(allMethods, path, handler, middlewares...)
+}
+
+// Name sets the name of the last added route, the name can be used to generate the route's url,
+// for Match, Any and Mount it's set for the routes of all the methods
+func (r *Router) Name(name string) *Router {
+	for _, route := range r.lastRoutes("can not set the name, no routes are added") {
+		route.Name = name
+	}
+	return r
+}
+
+// Where constrains the path param of the last added route to the given regex pattern, requests
+// with values that don't match the pattern get a 404 response, for Match, Any and Mount it's set
+// for the routes of all the methods
+func (r *Router) Where(param string, pattern string) *Router {
+	for _, route := range r.lastRoutes("can not set the constraint, no routes are added") {
+		constraints := map[string]string{}
+		for key, val := range route.Constraints {
+			constraints[key] = val
+		}
+		constraints[param] = pattern
+		route.Constraints = constraints
+	}
+	return r
+}
+
+// WithMiddlewareGroups creates a sub router, routes added to it use the given middleware groups
+// or middlewares registered by name
+func (r *Router) WithMiddlewareGroups(names ...string) *Router {
+	g := r.Group("")
+	g.middlewareNames = append(g.middlewareNames, names...)
+	return g
+}
+
+// Middleware adds the given middleware groups or middlewares registered by name to the last added route
+func (r *Router) Middleware(names ...string) *Router {
+	for _, route := range r.lastRoutes("can not set the middlewares, no routes are added") {
+		var mws []string
+		mws = append(mws, route.middlewareNames...)
+		route.middlewareNames = append(mws, names...)
+	}
 	return r
 }
 
+// WithoutMiddleware skips the middlewares registered under the given names for the last added route,
+// including global middlewares and the ones of middleware groups, e.g. the csrf middleware for
+// a webhook route, the middlewares are registered by name with App.RegisterMiddleware()
+func (r *Router) WithoutMiddleware(names ...string) *Router {
+	for _, route := range r.lastRoutes("can not skip the middlewares, no routes are added") {
+		var without []string
+		without = append(without, route.withoutMiddlewares...)
+		route.withoutMiddlewares = append(without, names...)
+	}
+	return r
+}
+
+// lastRoutes returns the routes added by the last call
+func (r *Router) lastRoutes(panicMsg string) []*Route {
+	rt := r.root()
+	if len(rt.Routes) == 0 {
+		panic(panicMsg)
+	}
+	n := rt.lastAdded
+	if n < 1 || n > len(rt.Routes) {
+		n = 1
+	}
+	var res []*Route
+	for i := len(rt.Routes) - n; i < len(rt.Routes); i++ {
+		res = append(res, &rt.Routes[i])
+	}
+	return res
+}
+
+// WhereNumber constrains the path param of the last added route to digits
+func (r *Router) WhereNumber(param string) *Router {
+	return r.Where(param, numericPattern)
+}
+
+// WhereAlpha constrains the path param of the last added route to letters
+func (r *Router) WhereAlpha(param string) *Router {
+	return r.Where(param, alphaPattern)
+}
+
+// WhereUUID constrains the path param of the last added route to uuids
+func (r *Router) WhereUUID(param string) *Router {
+	return r.Where(param, uuidPattern)
+}
+
+// URL generates the url of the route with the given name, the params fill the route's path params
+// and the placeholders of its host and the ones left are added to the query string, the url of a
+// route bound to a host is absolute, its scheme is https if App_USE_HTTPS is true
+func (r *Router) URL(name string, params map[string]interface{}) (string, error) {
+	route, ok := r.routeByName(name)
+	if !ok {
+		return "", fmt.Errorf("route %v is not defined", name)
+	}
+	return buildURL(route.Host, route.Path, params)
+}
+
 func (r *Router) GetRoutes() []Route {
-	return r.Routes
+	return r.root().Routes
+}
+
+func (r *Router) addRoute(method string, path string, handler Handler, middlewares []Middleware) *Router {
+	var mws []Middleware
+	mws = append(mws, r.middlewares...)
+	mws = append(mws, middlewares...)
+	rt := r.root()
+	route := Route{
+		Method:          method,
+		Host:            r.host,
+		Path:            joinPaths(r.prefix, path),
+		Handler:         handler,
+		Middlewares:     mws,
+		middlewareNames: r.middlewareNames,
+	}
+	rt.Routes = append(rt.Routes, route)
+	rt.lastAdded = 1
+	if r.version == "" {
+		return r
+	}
+	// the routes of a version group are dispatched by the version prefix and by the version
+	// requested in the headers, catch all routes at the root are dispatched by the prefix only
+	p := joinPaths(r.versionPrefix, strings.TrimPrefix(route.Path, joinPaths(r.versionPrefix, r.version)))
+	if strings.HasPrefix(p, "/*") {
+		return r
+	}
+	route.Path = p
+	route.Middlewares = append([]Middleware{varyByVersion}, mws...)
+	route.version = r.version
+	route.defaultVersion = r.defaultVersion
+	rt.Routes = append(rt.Routes, route)
+	rt.lastAdded = 2
+	return r
+}
+
+// root returns the top level router that holds the routes of all groups
+func (r *Router) root() *Router {
+	rt := r
+	for rt.parent != nil {
+		rt = rt.parent
+	}
+	return rt
+}
+
+func (r *Router) routeByName(name string) (Route, bool) {
+	for _, route := range r.root().Routes {
+		if route.Name != "" && route.Name == name {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+func buildURL(host string, path string, params map[string]interface{}) (string, error) {
+	used := map[string]bool{}
+	var base string
+	if host != "" {
+		labels := strings.Split(host, ".")
+		for i, label := range labels {
+			if !strings.HasPrefix(label, "{") || !strings.HasSuffix(label, "}") {
+				continue
+			}
+			key := label[1 : len(label)-1]
+			val, ok := params[key]
+			if !ok {
+				return "", fmt.Errorf("missing param %v for host %v", key, host)
+			}
+			used[key] = true
+			valStr := fmt.Sprintf("%v", val)
+			if !hostLabelRegex.MatchString(valStr) {
+				return "", fmt.Errorf("invalid param %v for host %v: %q", key, host, valStr)
+			}
+			labels[i] = strings.ToLower(valStr)
+		}
+		scheme := "http"
+		if useHTTPS, _ := strconv.ParseBool(os.Getenv("App_USE_HTTPS")); useHTTPS {
+			scheme = "https"
+		}
+		base = scheme + "://" + strings.Join(labels, ".")
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0:1] != ":" && segment[0:1] != "*") {
+			continue
+		}
+		key := segment[1:]
+		val, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("missing param %v for path %v", key, path)
+		}
+		used[key] = true
+		valStr := fmt.Sprintf("%v", val)
+		if segment[0:1] == ":" {
+			if valStr == "" {
+				return "", fmt.Errorf("empty param %v for path %v", key, path)
+			}
+			segments[i] = url.PathEscape(valStr)
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(valStr, "/"), "/")
+		for k, part := range parts {
+			parts[k] = url.PathEscape(part)
+		}
+		segments[i] = strings.Join(parts, "/")
+	}
+	res := base + strings.Join(segments, "/")
+	query := url.Values{}
+	for key, val := range params {
+		if !used[key] {
+			query.Add(key, fmt.Sprintf("%v", val))
+		}
+	}
+	if len(query) == 0 {
+		return res, nil
+	}
+	return res + "?" + query.Encode(), nil
+}
+
+func joinPaths(prefix string, path string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		if path == "" || path[0:1] != "/" {
+			return "/" + path
+		}
+		return path
+	}
+	if path == "" || path == "/" {
+		return "/" + prefix
+	}
+	return "/" + prefix + "/" + strings.TrimPrefix(path, "/")
 }
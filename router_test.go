@@ -6,6 +6,7 @@ package core
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -123,3 +124,172 @@ func TestAddMultipleRoutes(t *testing.T) {
 		t.Errorf("failed getting added routes")
 	}
 }
+
+func TestGroup(t *testing.T) {
+	r := NewRouter()
+	mw1 := Middleware(func(c *Context) { c.Next() })
+	mw2 := Middleware(func(c *Context) { c.Next() })
+	mw3 := Middleware(func(c *Context) { c.Next() })
+	handler := Handler(func(c *Context) *Response {
+		return nil
+	})
+	api := r.Group("/api/v1", mw1)
+	api.Get("/", handler)
+	users := api.Group("users", mw2)
+	users.Get("/:id", handler, mw3)
+	r.Post("/login", handler)
+
+	routes := r.GetRoutes()
+	if len(routes) != 3 {
+		t.Fatalf("failed testing group, expected 3 routes, found %v", len(routes))
+	}
+	if routes[0].Path != "/api/v1" || len(routes[0].Middlewares) != 1 {
+		t.Errorf("failed testing group")
+	}
+	if routes[1].Path != "/api/v1/users/:id" || len(routes[1].Middlewares) != 3 {
+		t.Errorf("failed testing nested group")
+	}
+	if reflect.ValueOf(routes[1].Middlewares[0]).Pointer() != reflect.ValueOf(mw1).Pointer() ||
+		reflect.ValueOf(routes[1].Middlewares[2]).Pointer() != reflect.ValueOf(mw3).Pointer() {
+		t.Errorf("failed testing nested group middlewares order")
+	}
+	if routes[2].Path != "/login" || len(routes[2].Middlewares) != 0 {
+		t.Errorf("failed testing group")
+	}
+	if len(users.GetRoutes()) != 3 {
+		t.Errorf("failed getting routes from a group")
+	}
+}
+
+func TestJoinPaths(t *testing.T) {
+	cases := [][]string{
+		{"", "/", "/"},
+		{"", "users", "/users"},
+		{"/api", "/", "/api"},
+		{"/api/", "/users", "/api/users"},
+		{"api", "users/:id", "/api/users/:id"},
+		{"/api", "/files/*filepath", "/api/files/*filepath"},
+	}
+	for _, c := range cases {
+		if p := joinPaths(c[0], c[1]); p != c[2] {
+			t.Errorf("failed joining paths %v and %v, expected %v found %v", c[0], c[1], c[2], p)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	r := NewRouter()
+	handler := Handler(func(c *Context) *Response {
+		return nil
+	})
+	r.Group("/users").Get("/:id/edit", handler).Name("users.edit")
+	r.Get("/", handler)
+	if r.GetRoutes()[0].Name != "users.edit" || r.GetRoutes()[1].Name != "" {
+		t.Errorf("failed testing naming routes")
+	}
+}
+
+func TestURL(t *testing.T) {
+	r := NewRouter()
+	handler := Handler(func(c *Context) *Response {
+		return nil
+	})
+	r.Get("/users/:id/edit", handler).Name("users.edit")
+	r.Get("/files/*filepath", handler).Name("files")
+	r.Get("/", handler).Name("home")
+
+	u, err := r.URL("users.edit", map[string]interface{}{"id": 5, "tab": "profile"})
+	if err != nil || u != "/users/5/edit?tab=profile" {
+		t.Errorf("failed generating url, found %v", u)
+	}
+	u, err = r.URL("files", map[string]interface{}{"filepath": "/docs/a b.md"})
+	if err != nil || u != "/files/docs/a%20b.md" {
+		t.Errorf("failed generating url with catch all param, found %v", u)
+	}
+	u, err = r.URL("home", nil)
+	if err != nil || u != "/" {
+		t.Errorf("failed generating url, found %v", u)
+	}
+	_, err = r.URL("users.edit", map[string]interface{}{"tab": "profile"})
+	if err == nil {
+		t.Errorf("expecting error for missing param")
+	}
+	_, err = r.URL("undefined", nil)
+	if err == nil {
+		t.Errorf("expecting error for undefined route")
+	}
+}
+
+func TestURLWithHost(t *testing.T) {
+	r := NewRouter()
+	handler := Handler(func(c *Context) *Response {
+		return nil
+	})
+	r.Domain("{tenant}.example.com").Get("/users/:id", handler).Name("tenant.users")
+	r.Domain("admin.example.com").Get("/", handler).Name("admin")
+
+	t.Setenv("App_USE_HTTPS", "true")
+	u, err := r.URL("tenant.users", map[string]interface{}{"tenant": "acme", "id": 5, "tab": "profile"})
+	if err != nil || u != "https://acme.example.com/users/5?tab=profile" {
+		t.Errorf("failed generating the url of a route bound to a host, found %v %v", u, err)
+	}
+	t.Setenv("App_USE_HTTPS", "false")
+	u, err = r.URL("admin", nil)
+	if err != nil || u != "http://admin.example.com/" {
+		t.Errorf("failed generating the url of a route bound to a static host, found %v %v", u, err)
+	}
+	if _, err = r.URL("tenant.users", map[string]interface{}{"id": 5}); err == nil {
+		t.Errorf("expecting error for missing host param")
+	}
+	if _, err = r.URL("tenant.users", map[string]interface{}{"tenant": "evil.com/x", "id": 5}); err == nil {
+		t.Errorf("expecting error for a host param that's not a single label")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	r := NewRouter()
+	handler := Handler(func(c *Context) *Response {
+		return nil
+	})
+	r.Get("/posts/:id/:slug", handler).WhereNumber("id").Where("slug", "[a-z-]+")
+	r.Get("/files/:uuid", handler).WhereUUID("uuid")
+	routes := r.GetRoutes()
+	if routes[0].Constraints["id"] != numericPattern || routes[0].Constraints["slug"] != "[a-z-]+" {
+		t.Errorf("failed testing route constraints")
+	}
+	if routes[1].Constraints["uuid"] != uuidPattern || len(routes[1].Constraints) != 1 {
+		t.Errorf("failed testing route constraints")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	r := NewRouter()
+	r.Fallback(Handler(func(c *Context) *Response {
+		return nil
+	}))
+	routes := r.GetRoutes()
+	if len(routes) != 2 || !routes[0].fallback || routes[0].Method != GET || routes[1].Method != HEAD || routes[0].Path != "/*path" {
+		t.Errorf("failed testing fallback route")
+	}
+}
+
+func TestMatchAndAny(t *testing.T) {
+	r := NewRouter()
+	handler := Handler(func(c *Context) *Response {
+		return nil
+	})
+	r.Match([]string{GET, "POST"}, "/login", handler).Name("login")
+	r.Any("/webhook", handler)
+	routes := r.GetRoutes()
+	if len(routes) != 2+len(allMethods) {
+		t.Fatalf("failed testing match and any, found %v routes", len(routes))
+	}
+	if routes[0].Method != GET || routes[1].Method != POST || routes[0].Name != "login" || routes[1].Name != "login" {
+		t.Errorf("failed testing match")
+	}
+	for i, method := range allMethods {
+		if routes[2+i].Method != method || routes[2+i].Path != "/webhook" || routes[2+i].Name != "" {
+			t.Errorf("failed testing any")
+		}
+	}
+}
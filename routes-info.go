@@ -0,0 +1,102 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method      string   `json:"method"`
+	Host        string   `json:"host"`
+	Path        string   `json:"path"`
+	Version     string   `json:"version"`
+	Name        string   `json:"name"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// GetRoutesInfo returns the metadata of the registered routes, the middlewares are listed in the
+// order they run including the global ones, by the name they are registered under if they have one
+func (r *Router) GetRoutesInfo() []RouteInfo {
+	res := []RouteInfo{}
+	for _, route := range r.GetRoutes() {
+		mws := []string{}
+		for _, nm := range routeMiddlewares(route) {
+			if nm.name != "" {
+				mws = append(mws, nm.name)
+				continue
+			}
+			mws = append(mws, middlewareName(nm.mw))
+		}
+		handler := route.handlerName
+		if handler == "" {
+			handler = funcName(route.Handler)
+		}
+		res = append(res, RouteInfo{
+			Method:      strings.ToUpper(route.Method),
+			Host:        route.Host,
+			Path:        route.Path,
+			Version:     route.version,
+			Name:        route.Name,
+			Handler:     handler,
+			Middlewares: mws,
+		})
+	}
+	return res
+}
+
+// RoutesTable renders the routes info as a table
+func (r *Router) RoutesTable() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tHOST\tPATH\tVERSION\tNAME\tHANDLER\tMIDDLEWARES")
+	for _, info := range r.GetRoutesInfo() {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", info.Method, info.Host, info.Path, info.Version, info.Name, info.Handler, strings.Join(info.Middlewares, ", "))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// RoutesJson renders the routes info as json
+func (r *Router) RoutesJson() (string, error) {
+	j, err := json.MarshalIndent(r.GetRoutesInfo(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(j), nil
+}
+
+// routeMiddlewares returns the middlewares that run before the route's handler
+func routeMiddlewares(route Route) []namedMiddleware {
+	if ResolveMiddlewares() != nil {
+		return ResolveMiddlewares().resolveNamed(route)
+	}
+	return (&Middlewares{}).resolveNamed(route)
+}
+
+// funcName returns the short name of the given function, e.g. controllers.(*Users).Show
+func funcName(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := strings.TrimSuffix(fn.Name(), "-fm")
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testRoutesInfoMiddleware(c *Context) {
+	c.Next()
+}
+
+func TestGetRoutesInfo(t *testing.T) {
+	NewMiddlewares()
+	UseMiddleware(testRoutesInfoMiddleware)
+	r := NewRouter()
+	r.Group("/api", testRoutesInfoMiddleware).Resource("/photos", testPhotosController{}, ResourceOptions{Only: []string{RESOURCE_SHOW}})
+	infos := r.GetRoutesInfo()
+	if len(infos) != 1 {
+		t.Fatalf("failed testing routes info")
+	}
+	info := infos[0]
+	if info.Method != "GET" || info.Path != "/api/photos/:id" || info.Name != "photos.show" {
+		t.Errorf("failed testing routes info")
+	}
+	if info.Handler != "core.testPhotosController.Show" {
+		t.Errorf("failed testing routes info handler name, found %v", info.Handler)
+	}
+	if len(info.Middlewares) != 2 || info.Middlewares[1] != "core.testRoutesInfoMiddleware" {
+		t.Errorf("failed testing routes info middlewares, found %v", info.Middlewares)
+	}
+}
+
+func TestRoutesTableAndJson(t *testing.T) {
+	NewMiddlewares()
+	r := NewRouter()
+	r.Get("/users/:id", Handler(testPhotosController{}.Show)).Name("users.show")
+	table := r.RoutesTable()
+	if !strings.Contains(table, "METHOD") || !strings.Contains(table, "/users/:id") || !strings.Contains(table, "users.show") {
+		t.Errorf("failed testing routes table")
+	}
+	j, err := r.RoutesJson()
+	if err != nil {
+		t.Errorf("failed testing routes json: %v", err)
+	}
+	var infos []RouteInfo
+	err = json.Unmarshal([]byte(j), &infos)
+	if err != nil || len(infos) != 1 || infos[0].Name != "users.show" {
+		t.Errorf("failed testing routes json")
+	}
+}
+
+func TestRoutesInfoWrappedMiddlewares(t *testing.T) {
+	NewMiddlewares()
+	r := NewRouter()
+	session := Terminable(testRoutesInfoMiddleware, func(c *Context) {})
+	r.Get("/users", Handler(func(c *Context) *Response { return nil }), Compress(), ETag(), SecurityHeaders(), session)
+	expected := []string{"core.Compress.func1", "core.ETag.func1", "core.SecurityHeaders.func1", "core.testRoutesInfoMiddleware"}
+	if found := r.GetRoutesInfo()[0].Middlewares; !reflect.DeepEqual(found, expected) {
+		t.Errorf("failed naming the wrapped middlewares, found %v", found)
+	}
+}
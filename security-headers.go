@@ -0,0 +1,122 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// SecurityHeadersOptions configures the security headers, the headers with empty values are not sent,
+// start from WebSecurityHeadersOptions() or APISecurityHeadersOptions() and change what you need
+type SecurityHeadersOptions struct {
+	// the max age of the Strict-Transport-Security header in seconds, it's sent over https only, see
+	// c.IsHTTPS(), 0 disables it
+	HSTSMaxAge            int
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// e.g. nosniff
+	ContentTypeOptions string
+	// e.g. DENY or SAMEORIGIN
+	FrameOptions string
+	// e.g. strict-origin-when-cross-origin
+	ReferrerPolicy string
+	// e.g. camera=(), microphone=()
+	PermissionsPolicy string
+	// the Content-Security-Policy, every CSP_NONCE in it is replaced with a nonce generated for each
+	// request, the nonce is returned by c.CSPNonce() for the inline scripts and styles of the page
+	ContentSecurityPolicy string
+	// sends the policy in the Content-Security-Policy-Report-Only header to try it without enforcing it
+	CSPReportOnly bool
+}
+
+// WebSecurityHeadersOptions returns the defaults for the routes that render html pages,
+// the inline scripts and styles are allowed if they have the nonce of the request
+func WebSecurityHeadersOptions() SecurityHeadersOptions {
+	return SecurityHeadersOptions{
+		HSTSMaxAge:            31536000,
+		HSTSIncludeSubdomains: true,
+		ContentTypeOptions:    "nosniff",
+		FrameOptions:          "SAMEORIGIN",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=()",
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'nonce-" + CSP_NONCE + "'; style-src 'self' 'nonce-" + CSP_NONCE + "'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'",
+	}
+}
+
+// APISecurityHeadersOptions returns the defaults for the routes that respond with data, e.g. json,
+// the responses are not allowed to load any resources or to be framed
+func APISecurityHeadersOptions() SecurityHeadersOptions {
+	return SecurityHeadersOptions{
+		HSTSMaxAge:            31536000,
+		HSTSIncludeSubdomains: true,
+		ContentTypeOptions:    "nosniff",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+	}
+}
+
+// SecurityHeaders returns a middleware that sets the security headers of the responses, the default
+// is WebSecurityHeadersOptions(), the headers the handler sets itself are kept, e.g. a relaxed
+// policy for a single page
+func SecurityHeaders(opts ...SecurityHeadersOptions) Middleware {
+	opt := WebSecurityHeadersOptions()
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	var hsts string
+	if opt.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%v", opt.HSTSMaxAge)
+		if opt.HSTSIncludeSubdomains {
+			hsts = hsts + "; includeSubDomains"
+		}
+		if opt.HSTSPreload {
+			hsts = hsts + "; preload"
+		}
+	}
+	cspHeader := "Content-Security-Policy"
+	if opt.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	withNonce := strings.Contains(opt.ContentSecurityPolicy, CSP_NONCE)
+	headers := []header{
+		{key: "X-Content-Type-Options", val: opt.ContentTypeOptions},
+		{key: "X-Frame-Options", val: opt.FrameOptions},
+		{key: "Referrer-Policy", val: opt.ReferrerPolicy},
+		{key: "Permissions-Policy", val: opt.PermissionsPolicy},
+	}
+	return Around(func(c *Context, next func() *Response) *Response {
+		if withNonce {
+			c.cspNonce = base64.StdEncoding.EncodeToString(randomBytes(16))
+		}
+		res := next()
+		for _, h := range headers {
+			if h.val != "" && res.GetHeader(h.key) == "" {
+				res.forceHeader(h.key, h.val)
+			}
+		}
+		if hsts != "" && c.IsHTTPS() && res.GetHeader("Strict-Transport-Security") == "" {
+			res.forceHeader("Strict-Transport-Security", hsts)
+		}
+		if opt.ContentSecurityPolicy != "" && res.GetHeader(cspHeader) == "" {
+			csp := opt.ContentSecurityPolicy
+			if withNonce {
+				csp = strings.ReplaceAll(csp, CSP_NONCE, c.cspNonce)
+			}
+			res.forceHeader(cspHeader, csp)
+		}
+		return res
+	})
+}
+
+// CSPNonce returns the nonce of the request's content security policy for the inline scripts
+// and styles, e.g. <script nonce="{{.nonce}}">, it's empty if the security headers
+// middleware doesn't run for the request or its policy has no nonce
+func (c *Context) CSPNonce() string {
+	c.checkReleased()
+	return c.cspNonce
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	page := Handler(func(c *Context) *Response {
+		return c.Response.HTML("<script nonce=\"" + c.CSPNonce() + "\">init()</script>")
+	})
+	r := NewRouter()
+	r.Get("/", page, SecurityHeaders())
+	r.Get("/embed", Handler(func(c *Context) *Response {
+		return c.Response.SetHeader("X-Frame-Options", "ALLOW-FROM https://partner.example.com").HTML("embed")
+	}), SecurityHeaders())
+	r.Get("/api/users", Handler(func(c *Context) *Response {
+		return c.Response.Json("[]")
+	}), SecurityHeaders(APISecurityHeadersOptions()))
+	opts := WebSecurityHeadersOptions()
+	opts.CSPReportOnly = true
+	opts.HSTSPreload = true
+	opts.PermissionsPolicy = ""
+	r.Get("/trial", page, SecurityHeaders(opts))
+	r.Get("/panic", Handler(func(c *Context) *Response { panic("failed") }), SecurityHeaders())
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	serve := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for key, val := range headers {
+			req.Header.Set(key, val)
+		}
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/", nil)
+	h := w.Header()
+	if h.Get("X-Content-Type-Options") != "nosniff" || h.Get("X-Frame-Options") != "SAMEORIGIN" || h.Get("Referrer-Policy") != "strict-origin-when-cross-origin" || h.Get("Permissions-Policy") == "" {
+		t.Errorf("failed setting the web security headers, found %v", h)
+	}
+	if h.Get("Strict-Transport-Security") != "" {
+		t.Errorf("expected no hsts header over http")
+	}
+	body := w.Body.String()
+	nonce := strings.TrimSuffix(strings.TrimPrefix(body, "<script nonce=\""), "\">init()</script>")
+	csp := h.Get("Content-Security-Policy")
+	if len(nonce) != 24 || !strings.Contains(csp, "script-src 'self' 'nonce-"+nonce+"'") || !strings.Contains(csp, "style-src 'self' 'nonce-"+nonce+"'") {
+		t.Errorf("failed setting the csp nonce, found %v in %v", nonce, h.Get("Content-Security-Policy"))
+	}
+	if other := serve("/", nil).Header().Get("Content-Security-Policy"); strings.Contains(other, nonce) {
+		t.Errorf("expected a new nonce for each request")
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, req)
+	if hsts := w.Header().Get("Strict-Transport-Security"); hsts != "max-age=31536000; includeSubDomains" {
+		t.Errorf("failed setting the hsts header over https, found %v", hsts)
+	}
+	h = serve("/", map[string]string{"X-Forwarded-Proto": "https"}).Header()
+	if h.Get("Strict-Transport-Security") != "" {
+		t.Errorf("expected X-Forwarded-Proto to be ignored for the requests not received from a trusted proxy")
+	}
+
+	h = serve("/embed", nil).Header()
+	if len(h.Values("X-Frame-Options")) != 1 || h.Get("X-Frame-Options") != "ALLOW-FROM https://partner.example.com" {
+		t.Errorf("expected the header set by the handler to be kept, found %v", h.Values("X-Frame-Options"))
+	}
+
+	h = serve("/api/users", nil).Header()
+	if h.Get("X-Frame-Options") != "DENY" || h.Get("Referrer-Policy") != "no-referrer" || h.Get("Content-Security-Policy") != "default-src 'none'; frame-ancestors 'none'" || h.Get("Permissions-Policy") != "" {
+		t.Errorf("failed setting the api security headers, found %v", h)
+	}
+
+	app.SetTrustedProxies("192.0.2.0/24")
+	h = serve("/trial", map[string]string{"X-Forwarded-Proto": "https"}).Header()
+	if h.Get("Content-Security-Policy") != "" || !strings.Contains(h.Get("Content-Security-Policy-Report-Only"), "'nonce-") || h.Get("Permissions-Policy") != "" {
+		t.Errorf("failed applying the customized options, found %v", h)
+	}
+	if h.Get("Strict-Transport-Security") != "max-age=31536000; includeSubDomains; preload" {
+		t.Errorf("failed setting the hsts preload, found %v", h.Get("Strict-Transport-Security"))
+	}
+
+	w = serve("/panic", nil)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("expected the error response to have the security headers, found %v %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type StaticOptions struct {
+	// enables listing the content of directories without an index file
+	Browse bool
+	// the file served for directories, default is index.html
+	Index string
+	// serves the index file of the root directory for missing paths without a file extension,
+	// useful for single page applications with client side routing
+	SPAFallback bool
+	// the Cache-Control header of the served files, e.g. "public, max-age=3600"
+	CacheControl string
+	// overrides CacheControl for specific file extensions, e.g. {".js": "public, max-age=31536000, immutable"}
+	CacheControlByExt map[string]string
+	// serves the precompressed .br or .gz version of a file if it exists and the client accepts it
+	Precompressed bool
+}
+
+type staticServer struct {
+	fsys  fs.FS
+	opts  StaticOptions
+	etags sync.Map
+}
+
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Static serves the files of the given directory under the given path prefix
+func (r *Router) Static(prefix string, dir string, opts ...StaticOptions) *Router {
+	return r.StaticFS(prefix, os.DirFS(dir), opts...)
+}
+
+// StaticFS serves the files of the given file system under the given path prefix, e.g. an embed.FS
+func (r *Router) StaticFS(prefix string, fsys fs.FS, opts ...StaticOptions) *Router {
+	var opt StaticOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Index == "" {
+		opt.Index = "index.html"
+	}
+	s := &staticServer{
+		fsys: fsys,
+		opts: opt,
+	}
+	p := joinPaths(prefix, "*filepath")
+	if joinPaths(r.prefix, p) == "/*filepath" {
+		// a catch all at the root would conflict with all other routes
+		return r.addFallback([]string{GET, HEAD}, p, s.serve, nil)
+	}
+	r.Get(p, s.serve)
+	r.Head(p, s.serve)
+	return r
+}
+
+func (s *staticServer) serve(c *Context) *Response {
+	reqPath := c.Request.httpPathParams.ByName("filepath")
+	name := strings.TrimPrefix(path.Clean("/"+reqPath), "/")
+	if name == "" {
+		name = "."
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		urlPath := c.Request.httpRequest.URL.Path
+		if !strings.HasSuffix(urlPath, "/") {
+			c.Response.redirectTo = path.Base(urlPath) + "/"
+			return c.Response
+		}
+		indexName := path.Join(name, s.opts.Index)
+		indexInfo, err := fs.Stat(s.fsys, indexName)
+		if err == nil && !indexInfo.IsDir() {
+			return s.serveFile(c, indexName, indexInfo)
+		}
+		if s.opts.Browse {
+			return s.listDir(c, name)
+		}
+		return s.notFound(c)
+	}
+	if err != nil {
+		if s.opts.SPAFallback && path.Ext(name) == "" {
+			indexInfo, err := fs.Stat(s.fsys, s.opts.Index)
+			if err == nil && !indexInfo.IsDir() {
+				return s.serveFile(c, s.opts.Index, indexInfo)
+			}
+		}
+		return s.notFound(c)
+	}
+	return s.serveFile(c, name, info)
+}
+
+func (s *staticServer) serveFile(c *Context, name string, info fs.FileInfo) *Response {
+	servedName := name
+	servedInfo := info
+	if s.opts.Precompressed {
+		acceptEncoding := c.GetHeader("Accept-Encoding")
+		for _, pc := range precompressedEncodings {
+			if !acceptsEncoding(acceptEncoding, pc.encoding) {
+				continue
+			}
+			pcInfo, err := fs.Stat(s.fsys, name+pc.ext)
+			if err != nil || pcInfo.IsDir() {
+				continue
+			}
+			servedName = name + pc.ext
+			servedInfo = pcInfo
+			c.Response.SetHeader("Content-Encoding", pc.encoding)
+			break
+		}
+		c.Response.SetHeader("Vary", "Accept-Encoding")
+	}
+	etag, err := s.etag(servedName, servedInfo)
+	if err != nil {
+		panic(fmt.Sprintf("error serving file %v: %v", name, err))
+	}
+	c.Response.SetHeader("ETag", etag)
+	if cc := s.cacheControl(name); cc != "" {
+		c.Response.SetHeader("Cache-Control", cc)
+	}
+	modTime := servedInfo.ModTime()
+	c.Response.stream = func(w http.ResponseWriter, r *http.Request) {
+		f, err := s.fsys.Open(servedName)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		content, ok := f.(io.ReadSeeker)
+		if !ok {
+			b, err := io.ReadAll(f)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			content = bytes.NewReader(b)
+		}
+		// the name of the original file is used so the content type is detected from its extension
+		http.ServeContent(w, r, name, modTime, content)
+	}
+	return c.Response
+}
+
+// etag returns an etag based on the modification time and size of the file, files with
+// no modification time like the ones of embed.FS get an etag based on their content
+func (s *staticServer) etag(name string, info fs.FileInfo) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf("\"%x-%x\"", info.ModTime().UnixNano(), info.Size()), nil
+	}
+	if etag, ok := s.etags.Load(name); ok {
+		return etag.(string), nil
+	}
+	b, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	etag := "\"" + hex.EncodeToString(sum[:16]) + "\""
+	s.etags.Store(name, etag)
+	return etag, nil
+}
+
+func (s *staticServer) cacheControl(name string) string {
+	if cc, ok := s.opts.CacheControlByExt[path.Ext(name)]; ok {
+		return cc
+	}
+	return s.opts.CacheControl
+}
+
+func (s *staticServer) listDir(c *Context, name string) *Response {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		return s.notFound(c)
+	}
+	var b strings.Builder
+	b.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		n := entry.Name()
+		if entry.IsDir() {
+			n = n + "/"
+		}
+		u := url.URL{Path: n}
+		fmt.Fprintf(&b, "<a href=\"%v\">%v</a>\n", html.EscapeString(u.String()), html.EscapeString(n))
+	}
+	b.WriteString("</pre>\n")
+	return c.Response.HTML(b.String())
+}
+
+func (s *staticServer) notFound(c *Context) *Response {
+	if c.app != nil {
+		return c.app.handleNotFound(c)
+	}
+	return defaultNotFoundHandler(c)
+}
+
+// acceptsEncoding checks whether the Accept-Encoding header value accepts the given encoding
+func acceptsEncoding(acceptEncoding string, encoding string) bool {
+	encodings := parseAcceptEncoding(acceptEncoding)
+	q, ok := encodings[encoding]
+	if !ok {
+		q, ok = encodings["*"]
+	}
+	return ok && q > 0
+}
+
+// parseAcceptEncoding parses an Accept-Encoding header value into a map of encodings and their q values
+func parseAcceptEncoding(acceptEncoding string) map[string]float64 {
+	res := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		q := 1.0
+		enc := part
+		if i := strings.Index(part, ";"); i != -1 {
+			enc = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err == nil {
+					q = v
+				}
+			}
+		}
+		res[strings.ToLower(enc)] = q
+	}
+	return res
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func testStaticFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>home</h1>")},
+		"app.js":         {Data: []byte("console.log('app')")},
+		"app.js.gz":      {Data: []byte("gzipped")},
+		"docs/guide.txt": {Data: []byte("guide")},
+	}
+}
+
+func serveStaticTestRequest(t *testing.T, hr http.Handler, path string, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, req)
+	rsp := w.Result()
+	b, _ := io.ReadAll(rsp.Body)
+	return rsp, string(b)
+}
+
+func TestStaticFS(t *testing.T) {
+	app := createNewApp(t)
+	r := NewRouter()
+	r.StaticFS("/assets", testStaticFS(), StaticOptions{
+		CacheControl:      "public, max-age=60",
+		CacheControlByExt: map[string]string{".js": "public, max-age=31536000, immutable"},
+		Precompressed:     true,
+	})
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	rsp, body := serveStaticTestRequest(t, hr, "/assets/app.js", nil)
+	if rsp.StatusCode != 200 || body != "console.log('app')" {
+		t.Errorf("failed serving static file, found %v %v", rsp.StatusCode, body)
+	}
+	if rsp.Header.Get("Cache-Control") != "public, max-age=31536000, immutable" || rsp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Errorf("failed setting static file headers")
+	}
+	etag := rsp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatalf("failed setting static file etag")
+	}
+	rsp, _ = serveStaticTestRequest(t, hr, "/assets/app.js", map[string]string{"If-None-Match": etag})
+	if rsp.StatusCode != http.StatusNotModified {
+		t.Errorf("failed responding with not modified, found %v", rsp.StatusCode)
+	}
+	rsp, body = serveStaticTestRequest(t, hr, "/assets/app.js", map[string]string{"Accept-Encoding": "br;q=0, gzip"})
+	if body != "gzipped" || rsp.Header.Get("Content-Encoding") != "gzip" || !strings.Contains(rsp.Header.Get(CONTENT_TYPE), "javascript") {
+		t.Errorf("failed serving precompressed file")
+	}
+	rsp, body = serveStaticTestRequest(t, hr, "/assets/", nil)
+	if rsp.StatusCode != 200 || body != "<h1>home</h1>" || rsp.Header.Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("failed serving directory index")
+	}
+	rsp, _ = serveStaticTestRequest(t, hr, "/assets/docs/", nil)
+	if rsp.StatusCode != 404 {
+		t.Errorf("failed disabling directory listing, found %v", rsp.StatusCode)
+	}
+	rsp, _ = serveStaticTestRequest(t, hr, "/assets/../core.go", nil)
+	if rsp.StatusCode == 200 {
+		t.Errorf("failed preventing path traversal")
+	}
+}
+
+func TestStaticBrowse(t *testing.T) {
+	app := createNewApp(t)
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "guide.txt"), []byte("guide"), 0644)
+	r := NewRouter()
+	r.Static("/files", dir, StaticOptions{Browse: true})
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	rsp, body := serveStaticTestRequest(t, hr, "/files/docs/", nil)
+	if rsp.StatusCode != 200 || !strings.Contains(body, "guide.txt") {
+		t.Errorf("failed listing directory")
+	}
+	rsp, _ = serveStaticTestRequest(t, hr, "/files/docs", nil)
+	if rsp.StatusCode != http.StatusPermanentRedirect || rsp.Header.Get("Location") != "/files/docs/" {
+		t.Errorf("failed redirecting to directory with trailing slash, found %v", rsp.StatusCode)
+	}
+	rsp, body = serveStaticTestRequest(t, hr, "/files/docs/guide.txt", nil)
+	if rsp.StatusCode != 200 || body != "guide" || rsp.Header.Get("Last-Modified") == "" {
+		t.Errorf("failed serving static file")
+	}
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	app := createNewApp(t)
+	r := NewRouter()
+	r.Get("/api/users", Handler(func(c *Context) *Response {
+		return c.Response.Json("[]")
+	}))
+	r.StaticFS("/", testStaticFS(), StaticOptions{SPAFallback: true})
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/api/users", 200, "[]"},
+		{"/app.js", 200, "console.log('app')"},
+		{"/dashboard/settings", 200, "<h1>home</h1>"},
+		{"/", 200, "<h1>home</h1>"},
+		{"/missing.js", 404, ""},
+	}
+	for _, cs := range cases {
+		rsp, body := serveStaticTestRequest(t, hr, cs.path, nil)
+		if rsp.StatusCode != cs.code || (cs.code == 200 && body != cs.body) {
+			t.Errorf("failed serving %v, found %v %v", cs.path, rsp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetTrustedProxies sets the ips or cidr ranges of the proxies in front of the app, e.g. 10.0.0.0/8,
+// the X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host headers are used only for the requests
+// received from them since any client can set them, it's used by c.ClientIP(), c.IsHTTPS() and
+// the rate limit, security headers and csrf middlewares
+func (app *App) SetTrustedProxies(proxies ...string) {
+	app.trustedProxies = parseTrustedProxies(proxies)
+}
+
+// ClientIP returns the ip of the client, if the request is received from a trusted proxy
+// it's the last ip in the X-Forwarded-For header that isn't a trusted proxy
+func (c *Context) ClientIP() string {
+	c.checkReleased()
+	return clientIP(c.Request.httpRequest, c.trustedProxies())
+}
+
+// IsHTTPS checks whether the request is received over https directly or through a trusted proxy
+func (c *Context) IsHTTPS() bool {
+	c.checkReleased()
+	return isHTTPS(c.Request.httpRequest, c.trustedProxies())
+}
+
+// host returns the host the client requested, it's the X-Forwarded-Host header for the requests
+// received from a trusted proxy
+func (c *Context) host() string {
+	r := c.Request.httpRequest
+	if isTrustedProxy(remoteIP(r), c.trustedProxies()) {
+		if h := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Host"), ",")[0]); h != "" {
+			return h
+		}
+	}
+	return r.Host
+}
+
+func (c *Context) trustedProxies() []*net.IPNet {
+	if c.app == nil {
+		return nil
+	}
+	return c.app.trustedProxies
+}
+
+// clientIP returns the ip of the client the request is received from, if it's received from a trusted
+// proxy the client ip is the last one in the X-Forwarded-For header that isn't a trusted proxy, the
+// entries before it can be set by the client so they are not used
+func clientIP(r *http.Request, proxies []*net.IPNet) string {
+	ip := remoteIP(r)
+	if !isTrustedProxy(ip, proxies) {
+		return ip
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(addr) == nil {
+			break
+		}
+		ip = addr
+		if !isTrustedProxy(addr, proxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// parseTrustedProxies parses the ips and cidr ranges of the trusted proxies
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	var res []*net.IPNet
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p = p + "/32"
+			} else {
+				p = p + "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			panic(fmt.Sprintf("invalid trusted proxy %v: %v", p, err))
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
+func isTrustedProxy(ip string, proxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range proxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHTTPS(r *http.Request, proxies []*net.IPNet) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return isTrustedProxy(remoteIP(r), proxies) && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
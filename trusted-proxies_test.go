@@ -0,0 +1,74 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5", "fd00::/8"})
+	tests := []struct {
+		remoteAddr string
+		forwarded  []string
+		proxies    []*net.IPNet
+		expected   string
+	}{
+		{"203.0.113.7:1234", nil, proxies, "203.0.113.7"},
+		{"203.0.113.7:1234", []string{"198.51.100.1"}, proxies, "203.0.113.7"},
+		{"10.0.0.1:1234", []string{"198.51.100.1"}, nil, "10.0.0.1"},
+		{"10.0.0.1:1234", []string{"198.51.100.1"}, proxies, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"1.1.1.1, 198.51.100.1, 192.168.1.5"}, proxies, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"1.1.1.1", "198.51.100.1, 10.0.0.2"}, proxies, "198.51.100.1"},
+		{"10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, proxies, "10.0.0.3"},
+		{"10.0.0.1:1234", []string{"spoofed, 10.0.0.2"}, proxies, "10.0.0.2"},
+		{"10.0.0.1:1234", nil, proxies, "10.0.0.1"},
+		{"[fd00::1]:1234", []string{"2001:db8::1"}, proxies, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		for _, f := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", f)
+		}
+		if ip := clientIP(req, tt.proxies); ip != tt.expected {
+			t.Errorf("expected the client ip of %v %v to be %v, found %v", tt.remoteAddr, tt.forwarded, tt.expected, ip)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected an invalid trusted proxy to panic")
+		}
+	}()
+	parseTrustedProxies([]string{"10.0.0.0/33"})
+}
+
+func TestContextClientIPAndScheme(t *testing.T) {
+	app := createNewApp(t)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Host = "app.internal"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "example.com, app.internal")
+	c := acquireContext(app, httptest.NewRecorder(), req, nil)
+	defer releaseContext(c)
+	if c.ClientIP() != "10.0.0.1" || c.IsHTTPS() || c.host() != "app.internal" {
+		t.Errorf("expected the forwarded headers to be ignored without trusted proxies, found %v %v %v", c.ClientIP(), c.IsHTTPS(), c.host())
+	}
+	app.SetTrustedProxies("10.0.0.0/8")
+	if c.ClientIP() != "198.51.100.1" || !c.IsHTTPS() || c.host() != "example.com" {
+		t.Errorf("failed using the forwarded headers of a trusted proxy, found %v %v %v", c.ClientIP(), c.IsHTTPS(), c.host())
+	}
+	req.Header.Del("X-Forwarded-Proto")
+	req.TLS = &tls.ConnectionState{}
+	if !c.IsHTTPS() {
+		t.Errorf("expected the request over tls to be https")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type VersionOptions struct {
+	// dispatches the requests that don't request a version to the routes of this version
+	Default bool
+	// marks the version as deprecated, its responses get the Deprecation header
+	Deprecated bool
+	// the date the version got deprecated, sent in the Deprecation header instead of "true"
+	DeprecatedAt time.Time
+	// the date the version stops being served, sent in the Sunset header
+	Sunset time.Time
+	// the url of the deprecation notice or the migration guide, sent in the Link header
+	Link string
+}
+
+// Version creates a sub router for the routes of the given api version, e.g. "v2", the routes get
+// dispatched for requests with the version prefix like /v2/users, and for requests to /users that
+// request the version through the Accept-Version header or a media type like application/vnd.app.v2+json
+// the version of the matched route is accessible in handlers through c.GetAPIVersion()
+func (r *Router) Version(version string, opts ...VersionOptions) *Router {
+	var opt VersionOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	version = normalizeVersion(version)
+	if version == "" {
+		panic("can not create the version group, the version is empty")
+	}
+	g := r.Group(version, versionMiddleware(version, opt))
+	g.version = version
+	g.versionPrefix = r.prefix
+	g.defaultVersion = opt.Default
+	return g
+}
+
+// GetAPIVersion returns the api version of the matched route, or an empty string if the route is not versioned
+func (c *Context) GetAPIVersion() string {
+	return c.apiVersion
+}
+
+// versionMiddleware sets the api version of the request and attaches
+// the deprecation headers to the responses of deprecated versions
+func versionMiddleware(version string, opt VersionOptions) Middleware {
+	var headers []header
+	if !opt.DeprecatedAt.IsZero() {
+		headers = append(headers, header{"Deprecation", fmt.Sprintf("@%v", opt.DeprecatedAt.Unix())})
+	} else if opt.Deprecated {
+		headers = append(headers, header{"Deprecation", "true"})
+	}
+	if !opt.Sunset.IsZero() {
+		headers = append(headers, header{"Sunset", opt.Sunset.UTC().Format(http.TimeFormat)})
+	}
+	if opt.Link != "" {
+		headers = append(headers, header{"Link", fmt.Sprintf("<%v>; rel=\"deprecation\"", opt.Link)})
+	}
+	return func(c *Context) {
+		c.apiVersion = version
+		for _, h := range headers {
+			c.Response.SetHeader(h.key, h.val)
+		}
+		c.Next()
+	}
+}
+
+// varyByVersion tells caches that the responses of the routes dispatched by the requested version vary by its headers
+func varyByVersion(c *Context) {
+	c.Response.SetHeader("Vary", "Accept-Version, Accept")
+	c.Next()
+}
+
+// matchVersion checks whether the version requested in the headers is the version of the route
+func (rc *routeCandidate) matchVersion(r *http.Request) bool {
+	requested := requestedVersion(r)
+	if requested == "" {
+		return rc.route.defaultVersion
+	}
+	return requested == rc.route.version
+}
+
+// requestedVersion returns the version requested through the Accept-Version header,
+// or through a media type like application/vnd.app.v2+json in the Accept header
+func requestedVersion(r *http.Request) string {
+	if v := normalizeVersion(r.Header.Get("Accept-Version")); v != "" {
+		return v
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.Split(part, ";")[0]))
+		if !strings.HasPrefix(mediaType, "application/vnd.") || !strings.HasSuffix(mediaType, "+json") {
+			continue
+		}
+		subtype := strings.TrimSuffix(strings.TrimPrefix(mediaType, "application/vnd."), "+json")
+		i := strings.LastIndex(subtype, ".v")
+		if i == -1 {
+			continue
+		}
+		if v := normalizeVersion(subtype[i+1:]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// normalizeVersion converts versions like "2", "V2" and "v2" to "v2"
+func normalizeVersion(version string) string {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	if version == "" {
+		return ""
+	}
+	return "v" + version
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Harran Ali <harran.m@gmail.com>. All rights reserved.
+// Use of this source code is governed by MIT-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gocondor/core/logger"
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	cases := map[string]string{"2": "v2", "V2": "v2", " v2.1 ": "v2.1", "": "", "v": ""}
+	for version, expected := range cases {
+		if res := normalizeVersion(version); res != expected {
+			t.Errorf("failed normalizing version %q, found %q", version, res)
+		}
+	}
+}
+
+func TestVersionDispatch(t *testing.T) {
+	app := createNewApp(t)
+	loggr = logger.NewLogger(&logger.LogNullDriver{})
+	r := NewRouter()
+	sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	api := r.Group("/api")
+	v1 := api.Version("v1", VersionOptions{Default: true, Deprecated: true, Sunset: sunset, Link: "https://example.com/migrate"})
+	v1.Get("/users/:id", Handler(func(c *Context) *Response {
+		return c.Response.Text(c.GetAPIVersion() + " user " + c.CastToString(c.GetPathParam("id")))
+	})).Name("v1.users.show")
+	v2 := api.Version("2")
+	v2.Get("/users/:id", Handler(func(c *Context) *Response {
+		return c.Response.Text(c.GetAPIVersion() + " user " + c.CastToString(c.GetPathParam("id")))
+	}))
+	v2.Group("/admin").Get("/stats", Handler(func(c *Context) *Response {
+		return c.Response.Text(c.GetAPIVersion() + " stats")
+	}))
+	hr := app.RegisterRoutes(r.GetRoutes(), httprouter.New())
+	cases := []struct {
+		path     string
+		headers  map[string]string
+		code     int
+		expected string
+	}{
+		{"/api/v1/users/1", nil, 200, "v1 user 1"},
+		{"/api/v2/users/1", map[string]string{"Accept-Version": "v1"}, 200, "v2 user 1"},
+		{"/api/users/1", nil, 200, "v1 user 1"},
+		{"/api/users/1", map[string]string{"Accept-Version": "2"}, 200, "v2 user 1"},
+		{"/api/users/1", map[string]string{"Accept": "application/vnd.app.v2+json"}, 200, "v2 user 1"},
+		{"/api/users/1", map[string]string{"Accept-Version": "v3"}, 404, ""},
+		{"/api/v2/admin/stats", nil, 200, "v2 stats"},
+		{"/api/admin/stats", map[string]string{"Accept-Version": "v2"}, 200, "v2 stats"},
+		{"/api/admin/stats", nil, 404, ""},
+	}
+	for _, cs := range cases {
+		req := httptest.NewRequest("GET", cs.path, nil)
+		for key, val := range cs.headers {
+			req.Header.Set(key, val)
+		}
+		w := httptest.NewRecorder()
+		hr.ServeHTTP(w, req)
+		rsp := w.Result()
+		b, _ := io.ReadAll(rsp.Body)
+		if rsp.StatusCode != cs.code || (cs.code == 200 && string(b) != cs.expected) {
+			t.Errorf("failed dispatching %v %v, found %v %v", cs.path, cs.headers, rsp.StatusCode, string(b))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/1", nil))
+	h := w.Result().Header
+	if h.Get("Deprecation") != "true" || h.Get("Sunset") != "Tue, 01 Jan 2030 00:00:00 GMT" || h.Get("Link") != "<https://example.com/migrate>; rel=\"deprecation\"" {
+		t.Errorf("failed attaching the deprecation headers, found %v", h)
+	}
+	if h.Get("Vary") != "Accept-Version, Accept" {
+		t.Errorf("failed attaching the vary header, found %v", h.Get("Vary"))
+	}
+	w = httptest.NewRecorder()
+	hr.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/users/1", nil))
+	if w.Result().Header.Get("Deprecation") != "" {
+		t.Errorf("expected no deprecation header for a supported version")
+	}
+
+	u, _ := r.URL("v1.users.show", map[string]interface{}{"id": 5})
+	if u != "/api/v1/users/5" {
+		t.Errorf("failed generating the url of a versioned route, found %v", u)
+	}
+}